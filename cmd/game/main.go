@@ -55,6 +55,14 @@ func main() {
 		),
 	}
 
+	for i := range app.Models {
+		if clips := app.Models[i].Animations(); len(clips) > 0 {
+			if err := app.Models[i].Play(clips[0], true); err != nil {
+				logger.Errorf("%v", err)
+			}
+		}
+	}
+
 	previousTime := glfw.GetTime()
 
 	for !app.Window.ShouldClose() {
@@ -63,6 +71,10 @@ func main() {
 		app.TimeAccum = currentTime - previousTime
 		previousTime = currentTime
 
+		for i := range app.Models {
+			app.Models[i].Update(app.TimeAccum)
+		}
+
 		app.Draw()
 
 		app.Window.SwapBuffers()
@@ -70,7 +82,7 @@ func main() {
 	}
 
 	logger.Infof("Exiting game loop")
-	glfw.Terminate()
+	app.Close()
 
 	logger.Infof("Game closed")
 }
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/joaqu1m/gogl-playground/libs/gltfloader"
+)
+
+// animationState guarda o estado do player de animação de um Model.
+type animationState struct {
+	clip    *gltfloader.Animation
+	time    float64
+	playing bool
+	loop    bool
+}
+
+// Animations lista os nomes dos clips disponíveis no modelo.
+func (m *Model) Animations() []string {
+	names := make([]string, 0, len(m.LoadedModel.Animations))
+	for _, a := range m.LoadedModel.Animations {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// Play inicia o clip pelo nome a partir do começo.
+func (m *Model) Play(name string, loop bool) error {
+	clip := m.LoadedModel.Animation(name)
+	if clip == nil {
+		return fmt.Errorf("model %s: animação %q não encontrada", m.Name, name)
+	}
+
+	m.anim = animationState{
+		clip:    clip,
+		playing: true,
+		loop:    loop,
+	}
+	m.LoadedModel.ApplyAnimation(clip, 0)
+	return nil
+}
+
+// Pause congela o clip atual no tempo corrente.
+func (m *Model) Pause() {
+	m.anim.playing = false
+}
+
+// Resume continua o clip atual de onde parou.
+func (m *Model) Resume() {
+	if m.anim.clip != nil {
+		m.anim.playing = true
+	}
+}
+
+// SetLoop define se o clip atual recomeça ao chegar no fim.
+func (m *Model) SetLoop(loop bool) {
+	m.anim.loop = loop
+}
+
+// IsPlaying informa se há um clip tocando.
+func (m *Model) IsPlaying() bool {
+	return m.anim.clip != nil && m.anim.playing
+}
+
+// Seek posiciona o clip atual no tempo t (em segundos) e aplica a pose.
+func (m *Model) Seek(t float64) {
+	if m.anim.clip == nil {
+		return
+	}
+	m.anim.time = m.wrapTime(t)
+	m.LoadedModel.ApplyAnimation(m.anim.clip, float32(m.anim.time))
+}
+
+// Update avança o clip atual em dt segundos. Deve ser chamado uma vez por frame.
+func (m *Model) Update(dt float64) {
+	if m.anim.clip == nil || !m.anim.playing {
+		return
+	}
+
+	duration := float64(m.anim.clip.Duration)
+	t := m.anim.time + dt
+	if !m.anim.loop && t >= duration {
+		t = duration
+		m.anim.playing = false
+	}
+	m.anim.time = m.wrapTime(t)
+	m.LoadedModel.ApplyAnimation(m.anim.clip, float32(m.anim.time))
+}
+
+// wrapTime mantém t dentro de [0, duration], dando a volta em modo loop.
+func (m *Model) wrapTime(t float64) float64 {
+	duration := float64(m.anim.clip.Duration)
+	if duration <= 0 {
+		return 0
+	}
+	if m.anim.loop {
+		t = math.Mod(t, duration)
+		if t < 0 {
+			t += duration
+		}
+		return t
+	}
+	return math.Max(0, math.Min(t, duration))
+}
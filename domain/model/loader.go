@@ -0,0 +1,222 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joaqu1m/gogl-playground/libs/entities"
+	"github.com/joaqu1m/gogl-playground/libs/gltfloader"
+	"github.com/joaqu1m/gogl-playground/libs/logger"
+)
+
+// LoadState é a etapa em que um carregamento assíncrono está.
+type LoadState int
+
+const (
+	LoadDecoding  LoadState = iota // lendo o arquivo e decodificando imagens
+	LoadUploading                  // na fila de envio ao OpenGL
+	LoadDone
+	LoadFailed
+)
+
+// errLoaderClosed é o erro dos carregamentos cancelados por AsyncLoader.Close.
+var errLoaderClosed = errors.New("carregamento cancelado")
+
+// LoadHandle acompanha um carregamento iniciado por AsyncLoader.Load. Os
+// métodos podem ser chamados de qualquer goroutine.
+type LoadHandle struct {
+	Name     string
+	FilePath string
+
+	transform entities.Transform
+
+	mu       sync.Mutex
+	state    LoadState
+	progress float32
+	model    Model
+	err      error
+	done     chan struct{}
+
+	// Só é acessado na thread GL, por AsyncLoader.Pump
+	uploader *gltfloader.Uploader
+}
+
+// State retorna a etapa atual do carregamento.
+func (h *LoadHandle) State() LoadState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.state
+}
+
+// Progress retorna o progresso estimado, de 0 a 1. O decode conta como a
+// primeira metade e o envio ao OpenGL como a segunda.
+func (h *LoadHandle) Progress() float32 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.progress
+}
+
+// Err retorna o erro do carregamento, ou nil se ele não falhou.
+func (h *LoadHandle) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+// Model retorna o modelo carregado. O bool é false enquanto o carregamento
+// não terminou com sucesso.
+func (h *LoadHandle) Model() (Model, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.model, h.state == LoadDone
+}
+
+// Done é fechado quando o carregamento termina, com sucesso ou não. Não
+// espere nele na thread GL: o envio só anda quando Pump é chamado.
+func (h *LoadHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+func (h *LoadHandle) setProgress(state LoadState, progress float32) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.state = state
+	h.progress = progress
+}
+
+func (h *LoadHandle) finish(m Model, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		h.state = LoadFailed
+		h.err = err
+		logger.Errorf("Failed to load model %s from path %s: %v", h.Name, h.FilePath, err)
+	} else {
+		h.state = LoadDone
+		h.progress = 1
+		h.model = m
+		logger.Debugf("Loaded model %s from path %s", h.Name, h.FilePath)
+	}
+	close(h.done)
+}
+
+// AsyncLoader carrega modelos sem travar a thread de render: o parse do
+// arquivo e a decodificação das imagens rodam em goroutines, e o envio ao
+// OpenGL fica numa fila que a thread GL consome aos poucos com Pump.
+type AsyncLoader struct {
+	mu      sync.Mutex
+	pending []*LoadHandle // decodificados, aguardando envio, em ordem de chegada
+	closed  bool
+}
+
+// NewAsyncLoader cria um loader com a fila de envio vazia.
+func NewAsyncLoader() *AsyncLoader {
+	return &AsyncLoader{}
+}
+
+// Load começa a carregar o arquivo em background e retorna imediatamente.
+func (l *AsyncLoader) Load(name, filePath string, transform entities.Transform, opts gltfloader.Options) *LoadHandle {
+	h := &LoadHandle{
+		Name:      name,
+		FilePath:  filePath,
+		transform: transform,
+		state:     LoadDecoding,
+		done:      make(chan struct{}),
+	}
+
+	logger.Debugf("Loading model %s from path %s (async)", name, filePath)
+
+	go func() {
+		data, err := gltfloader.DecodeFile(filePath, opts)
+		if err != nil {
+			h.finish(Model{}, err)
+			return
+		}
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.closed {
+			h.finish(Model{}, errLoaderClosed)
+			return
+		}
+		h.uploader = gltfloader.NewUploader(data)
+		h.setProgress(LoadUploading, 0.5)
+		l.pending = append(l.pending, h)
+	}()
+
+	return h
+}
+
+// Pending retorna quantos carregamentos aguardam envio ao OpenGL.
+func (l *AsyncLoader) Pending() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.pending)
+}
+
+// Pump envia ao OpenGL o que estiver na fila até estourar budget, e retorna
+// os carregamentos que terminaram com sucesso nesta chamada. Sempre executa
+// pelo menos um passo, para que a fila ande mesmo com budget pequeno. Precisa
+// ser chamado na thread que possui o contexto GL.
+func (l *AsyncLoader) Pump(budget time.Duration) []*LoadHandle {
+	start := time.Now()
+	var finished []*LoadHandle
+
+	for first := true; first || time.Since(start) < budget; first = false {
+		h := l.front()
+		if h == nil {
+			break
+		}
+
+		u := h.uploader
+		if err := u.Step(); err != nil {
+			l.popFront()
+			h.finish(Model{}, err)
+			continue
+		}
+
+		if !u.Done() {
+			h.setProgress(LoadUploading, 0.5+0.5*float32(u.Completed())/float32(u.Steps()))
+			continue
+		}
+
+		l.popFront()
+		h.finish(newModel(h.Name, h.FilePath, h.transform, u.Model()), nil)
+		finished = append(finished, h)
+	}
+
+	return finished
+}
+
+// Close cancela os carregamentos pendentes, liberando o que já tinha sido
+// enviado ao OpenGL. Carregamentos ainda decodificando são cancelados quando
+// terminarem. Precisa ser chamado na thread que possui o contexto GL.
+func (l *AsyncLoader) Close() {
+	l.mu.Lock()
+	pending := l.pending
+	l.pending = nil
+	l.closed = true
+	l.mu.Unlock()
+
+	for _, h := range pending {
+		h.uploader.Model().Release()
+		h.finish(Model{}, fmt.Errorf("model %s: %w", h.Name, errLoaderClosed))
+	}
+}
+
+func (l *AsyncLoader) front() *LoadHandle {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.pending) == 0 {
+		return nil
+	}
+	return l.pending[0]
+}
+
+func (l *AsyncLoader) popFront() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = l.pending[1:]
+}
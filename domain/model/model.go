@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+
 	"github.com/joaqu1m/gogl-playground/libs/entities"
 	"github.com/joaqu1m/gogl-playground/libs/gltfloader"
 	"github.com/joaqu1m/gogl-playground/libs/logger"
@@ -11,19 +13,25 @@ type Model struct {
 	FilePath    string
 	Transform   entities.Transform
 	LoadedModel gltfloader.GLTFModel
+
+	anim animationState
 }
 
 func NewModel(name, filePath string, transform entities.Transform) Model {
 
 	logger.Debugf("Loading model %s from path %s", name, filePath)
 
-	loaded, err := gltfloader.LoadGLB(filePath)
+	loaded, err := gltfloader.LoadGLB(filePath, gltfloader.DefaultOptions())
 	if err != nil || loaded == nil {
 		logger.Fatalf("Failed to load model %s from path %s: %v", name, filePath, err)
 	}
 
 	logger.Debugf("Loaded model %s from path %s", name, filePath)
 
+	return newModel(name, filePath, transform, loaded)
+}
+
+func newModel(name, filePath string, transform entities.Transform, loaded *gltfloader.GLTFModel) Model {
 	return Model{
 		Name:        name,
 		FilePath:    filePath,
@@ -31,3 +39,47 @@ func NewModel(name, filePath string, transform entities.Transform) Model {
 		LoadedModel: *loaded,
 	}
 }
+
+// Release libera os recursos OpenGL do modelo. Precisa do contexto GL.
+func (m *Model) Release() {
+	logger.Debugf("Releasing model %s", m.Name)
+	m.anim = animationState{}
+	m.LoadedModel.Release()
+}
+
+// SetMorphWeights define os pesos dos morph targets de todas as primitivas
+// da mesh com o nome dado. A deformação é aplicada no próximo Draw.
+func (m *Model) SetMorphWeights(meshName string, weights []float32) error {
+	found := false
+	for _, mesh := range m.LoadedModel.Meshes {
+		if mesh.Name != meshName || mesh.Morph == nil {
+			continue
+		}
+		mesh.SetMorphWeights(weights)
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("model %s: mesh %q sem morph targets", m.Name, meshName)
+	}
+	return nil
+}
+
+// MorphWeights retorna uma cópia dos pesos atuais da mesh, ou nil se ela não
+// existir ou não tiver morph targets.
+func (m *Model) MorphWeights(meshName string) []float32 {
+	for _, mesh := range m.LoadedModel.Meshes {
+		if mesh.Name == meshName && mesh.Morph != nil {
+			return append([]float32(nil), mesh.Morph.Weights...)
+		}
+	}
+	return nil
+}
+
+// Cameras lista os nomes das câmeras importadas do modelo, na ordem da cena.
+func (m *Model) Cameras() []string {
+	names := make([]string, 0, len(m.LoadedModel.Cameras))
+	for _, c := range m.LoadedModel.Cameras {
+		names = append(names, c.Name)
+	}
+	return names
+}
@@ -0,0 +1,33 @@
+package model
+
+import "fmt"
+
+// Variants lista os nomes das variantes de material do modelo
+// (KHR_materials_variants).
+func (m *Model) Variants() []string {
+	return append([]string(nil), m.LoadedModel.Variants...)
+}
+
+// SetVariant troca os materiais do modelo para os da variante pelo nome,
+// sem recarregar o arquivo. Com name vazio volta aos materiais padrão.
+func (m *Model) SetVariant(name string) error {
+	if name == "" {
+		m.LoadedModel.SetVariant(-1)
+		return nil
+	}
+	idx := m.LoadedModel.VariantIndex(name)
+	if idx < 0 {
+		return fmt.Errorf("model %s: variante %q não encontrada", m.Name, name)
+	}
+	m.LoadedModel.SetVariant(idx)
+	return nil
+}
+
+// ActiveVariant retorna o nome da variante ativa, ou "" com os materiais
+// padrão.
+func (m *Model) ActiveVariant() string {
+	if idx := m.LoadedModel.ActiveVariant(); idx >= 0 {
+		return m.LoadedModel.Variants[idx]
+	}
+	return ""
+}
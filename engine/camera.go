@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/joaqu1m/gogl-playground/domain/model"
+	"github.com/joaqu1m/gogl-playground/gmath"
+	"github.com/joaqu1m/gogl-playground/libs/gltfloader"
+)
+
+// cameraSelection aponta para uma câmera importada de um dos Models.
+type cameraSelection struct {
+	set    bool
+	model  int // índice em App.Models
+	camera int // índice em LoadedModel.Cameras
+}
+
+// defaultEye é a posição da câmera padrão, usada sem câmera ativa.
+var defaultEye = [3]float32{0, 0.8, 3.0}
+
+// SetActiveCamera passa a renderizar pela câmera cameraName importada do
+// model modelName. A câmera acompanha o transform do Model e as animações
+// do nó dela.
+func (a *App) SetActiveCamera(modelName, cameraName string) error {
+	for i := range a.Models {
+		if a.Models[i].Name != modelName {
+			continue
+		}
+		for j, c := range a.Models[i].LoadedModel.Cameras {
+			if c.Name == cameraName {
+				a.camera = cameraSelection{set: true, model: i, camera: j}
+				return nil
+			}
+		}
+		return fmt.Errorf("model %s: câmera %q não encontrada", modelName, cameraName)
+	}
+	return fmt.Errorf("model %s não encontrado", modelName)
+}
+
+// UseDefaultCamera volta para a câmera fixa padrão.
+func (a *App) UseDefaultCamera() {
+	a.camera = cameraSelection{}
+}
+
+// activeCamera resolve a câmera selecionada, ou nil se não houver (ou se a
+// seleção não for mais válida).
+func (a *App) activeCamera() (*model.Model, *gltfloader.Camera) {
+	sel := a.camera
+	if !sel.set || sel.model >= len(a.Models) {
+		return nil, nil
+	}
+	entry := &a.Models[sel.model]
+	if sel.camera >= len(entry.LoadedModel.Cameras) {
+		return nil, nil
+	}
+	return entry, entry.LoadedModel.Cameras[sel.camera]
+}
+
+// viewProjection retorna view, projection e a posição da câmera ativa, ou
+// da câmera padrão.
+func (a *App) viewProjection() (gmath.Mat4, gmath.Mat4, [3]float32) {
+	aspect := float32(a.Width) / float32(a.Height)
+
+	entry, cam := a.activeCamera()
+	if cam == nil {
+		view := gmath.MatLookAt(defaultEye, [3]float32{0, 0, 0}, [3]float32{0, 1, 0})
+		proj := gmath.MatPerspective(float32(45.0*math.Pi/180.0), aspect, 0.1, 100.0)
+		return view, proj, defaultEye
+	}
+
+	world := gmath.MatMul(modelMatrix(entry), gmath.Mat4(cam.World))
+	eye := [3]float32{world[12], world[13], world[14]}
+	return gmath.MatInverse(world), cameraProjection(cam, aspect), eye
+}
+
+// cameraProjection monta a projeção de uma câmera glTF. Câmeras sem
+// aspectRatio usam o do viewport.
+func cameraProjection(cam *gltfloader.Camera, viewportAspect float32) gmath.Mat4 {
+	if cam.Type == gltfloader.CameraOrthographic {
+		return gmath.MatOrthographic(cam.XMag, cam.YMag, cam.ZNear, cam.ZFar)
+	}
+
+	aspect := cam.AspectRatio
+	if aspect <= 0 {
+		aspect = viewportAspect
+	}
+	if cam.ZFar <= 0 {
+		return gmath.MatPerspectiveInfinite(cam.YFov, aspect, cam.ZNear)
+	}
+	return gmath.MatPerspective(cam.YFov, aspect, cam.ZNear, cam.ZFar)
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/joaqu1m/gogl-playground/gmath"
+	"github.com/joaqu1m/gogl-playground/libs/gltfloader"
+)
+
+// maxLights precisa bater com MAX_LIGHTS do fragment shader.
+const maxLights = 8
+
+// defaultLightDir é a luz direcional usada quando nenhum Model traz luzes.
+var defaultLightDir = [3]float32{-0.3, -0.8, -0.5}
+
+// bindLights envia as luzes KHR_lights_punctual de todos os Models, já em
+// espaço de mundo. Sem luzes importadas, usa uma direcional padrão com
+// intensidade PI, que reproduz o Lambert sem normalização de antes do PBR.
+func (a *App) bindLights() {
+	p := a.ShaderProgram
+	count := 0
+
+	for i := range a.Models {
+		entry := &a.Models[i]
+		base := modelMatrix(entry)
+		for _, l := range entry.LoadedModel.Lights {
+			if count == maxLights {
+				// Luzes além do limite do shader são ignoradas
+				break
+			}
+			world := gmath.MatMul(base, gmath.Mat4(l.World))
+			setLight(p, count, l, world)
+			count++
+		}
+	}
+
+	if count == 0 {
+		setLightUniforms(p, 0, int32(gltfloader.LightDirectional),
+			[3]float32{math.Pi, math.Pi, math.Pi}, [3]float32{}, defaultLightDir, 0, 1, 0)
+		count = 1
+	}
+
+	gmath.SetUniformInt(p, "lightCount", int32(count))
+}
+
+// setLight envia a luz l na posição i dos arrays de luz do shader.
+func setLight(p uint32, i int, l *gltfloader.Light, world gmath.Mat4) {
+	pos := [3]float32{world[12], world[13], world[14]}
+	dir := [3]float32{-world[8], -world[9], -world[10]} // -Z do nó
+
+	color := [3]float32{l.Color[0] * l.Intensity, l.Color[1] * l.Intensity, l.Color[2] * l.Intensity}
+
+	innerCos := float32(math.Cos(float64(l.InnerConeAngle)))
+	outerCos := float32(math.Cos(float64(l.OuterConeAngle)))
+	setLightUniforms(p, i, int32(l.Type), color, pos, dir, l.Range, innerCos, outerCos)
+}
+
+func setLightUniforms(p uint32, i int, kind int32, color, pos, dir [3]float32, lightRange, innerCos, outerCos float32) {
+	field := func(name string) string { return fmt.Sprintf("lights[%d].%s", i, name) }
+
+	gmath.SetUniformInt(p, field("type"), kind)
+	gmath.SetUniformVec3(p, field("color"), color)
+	gmath.SetUniformVec3(p, field("position"), pos)
+	gmath.SetUniformVec3(p, field("direction"), dir)
+	gmath.SetUniformFloat(p, field("range"), lightRange)
+	gmath.SetUniformFloat(p, field("innerCos"), innerCos)
+	gmath.SetUniformFloat(p, field("outerCos"), outerCos)
+}
@@ -1,12 +1,16 @@
 package engine
 
 import (
-	"math"
+	"sort"
+	"time"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/joaqu1m/gogl-playground/domain/model"
 	"github.com/joaqu1m/gogl-playground/gmath"
+	"github.com/joaqu1m/gogl-playground/libs/entities"
+	"github.com/joaqu1m/gogl-playground/libs/gltfloader"
+	"github.com/joaqu1m/gogl-playground/libs/gltrack"
 )
 
 type App struct {
@@ -17,6 +21,22 @@ type App struct {
 	TimeAccum     float64
 	Angle         float64
 	Models        []model.Model
+
+	// Loader carrega modelos em background; os envios ao OpenGL são feitos
+	// no começo de cada Draw, gastando no máximo UploadBudget por frame.
+	Loader       *model.AsyncLoader
+	UploadBudget time.Duration
+
+	camera cameraSelection
+	joints jointPalette
+}
+
+// drawCall é uma mesh pronta para desenhar, com o transform já resolvido.
+type drawCall struct {
+	loaded   *gltfloader.GLTFModel
+	mesh     *gltfloader.GLTFMesh
+	modelMat gmath.Mat4
+	depth    float32 // distância até a câmera, usada para ordenar transparentes
 }
 
 func NewApp(width, height int, title string) *App {
@@ -37,9 +57,73 @@ func NewApp(width, height int, title string) *App {
 		Height:        height,
 		ShaderProgram: createShaderProgram(),
 		Models:        []model.Model{},
+		Loader:        model.NewAsyncLoader(),
+		UploadBudget:  4 * time.Millisecond,
+		joints:        newJointPalette(),
+	}
+}
+
+// LoadModelAsync começa a carregar um modelo em background. Quando o envio ao
+// OpenGL termina, o modelo é adicionado a Models automaticamente.
+func (a *App) LoadModelAsync(name, filePath string, transform entities.Transform) *model.LoadHandle {
+	return a.Loader.Load(name, filePath, transform, gltfloader.DefaultOptions())
+}
+
+// processUploads consome a fila de envio do Loader dentro do UploadBudget e
+// adiciona à cena os modelos que terminaram.
+func (a *App) processUploads() {
+	for _, h := range a.Loader.Pump(a.UploadBudget) {
+		if m, ok := h.Model(); ok {
+			a.Models = append(a.Models, m)
+		}
+	}
+}
+
+// RemoveModel libera os recursos OpenGL do Model no índice i e o tira da
+// cena. Precisa do contexto GL.
+func (a *App) RemoveModel(i int) {
+	if i < 0 || i >= len(a.Models) {
+		return
+	}
+	a.Models[i].Release()
+	a.Models = append(a.Models[:i], a.Models[i+1:]...)
+
+	// A câmera ativa aponta por índice para a lista de Models
+	if a.camera.set {
+		switch {
+		case a.camera.model == i:
+			a.UseDefaultCamera()
+		case a.camera.model > i:
+			a.camera.model--
+		}
 	}
 }
 
+// Close libera todos os Models e o shader, fecha a janela e finaliza o
+// GLFW. Em modo debug (GOGL_DEBUG_GL=1) loga os objetos OpenGL que ficaram
+// vivos.
+func (a *App) Close() {
+	a.Loader.Close()
+	for i := range a.Models {
+		a.Models[i].Release()
+	}
+	a.Models = nil
+	a.UseDefaultCamera()
+
+	a.joints.release()
+
+	if a.ShaderProgram != 0 {
+		gl.DeleteProgram(a.ShaderProgram)
+		gltrack.Untrack(gltrack.Program, a.ShaderProgram)
+		a.ShaderProgram = 0
+	}
+
+	gltrack.LogLeaks()
+
+	a.Window.Destroy()
+	glfw.Terminate()
+}
+
 func (a *App) Draw() {
 
 	gl.ClearColor(0.1, 0.1, 0.15, 1.0)
@@ -47,74 +131,277 @@ func (a *App) Draw() {
 
 	gl.UseProgram(a.ShaderProgram)
 
-	viewMat := gmath.MatLookAt(
-		[3]float32{0, 0.8, 3.0},
-		[3]float32{0, 0, 0},
-		[3]float32{0, 1, 0},
-	)
+	a.processUploads()
 
-	projMat := gmath.MatPerspective(
-		float32(45.0*math.Pi/180.0),
-		float32(a.Width)/float32(a.Height),
-		0.1, 100.0,
-	)
+	// Propaga nós movidos pelo jogo desde o último frame
+	for i := range a.Models {
+		a.Models[i].LoadedModel.UpdateWorldTransforms()
+	}
+
+	viewMat, projMat, eye := a.viewProjection()
 
 	gmath.SetUniformMat4(a.ShaderProgram, "view", viewMat)
 	gmath.SetUniformMat4(a.ShaderProgram, "projection", projMat)
-	gmath.SetUniformVec3(a.ShaderProgram, "lightDir", [3]float32{-0.3, -0.8, -0.5})
+	gmath.SetUniformVec3(a.ShaderProgram, "cameraPos", eye)
+	a.bindLights()
 
-	// ----------- Render por modelo -----------
+	// ----------- Monta a lista de draw calls por modelo -----------
 
-	for _, entry := range a.Models {
+	var opaque, blended []drawCall
 
-		t := entry.Transform
+	for i := range a.Models {
+		entry := &a.Models[i]
+		baseMat := modelMatrix(entry)
 
-		// Usa apenas a rotação definida no Transform
-		rotMat := t.Rotation.Normalize().ToMat4()
-		transMat := gmath.MatTranslate(t.Position)
-		scaleMat := gmath.MatScale(t.Scale.X, t.Scale.Y, t.Scale.Z)
+		for _, m := range entry.LoadedModel.Meshes {
 
-		// Ordem correta: T * R * S
-		baseMat := gmath.MatMul(
-			transMat,
-			gmath.MatMul(rotMat, scaleMat),
-		)
+			// Meshes skinned ignoram o transform do próprio nó: a palette já
+			// leva os vértices para o espaço do modelo.
+			modelMat := baseMat
+			if m.Skin < 0 {
+				modelMat = gmath.MatMul(baseMat, gmath.Mat4(m.Transform))
+			}
 
-		for _, m := range entry.LoadedModel.Meshes {
+			dc := drawCall{loaded: &entry.LoadedModel, mesh: m, modelMat: modelMat}
 
-			modelMat := gmath.MatMul(baseMat, gmath.Mat4(m.Transform))
-			gmath.SetUniformMat4(a.ShaderProgram, "model", modelMat)
+			if m.Material.AlphaMode == gltfloader.AlphaBlend {
+				dx := modelMat[12] - eye[0]
+				dy := modelMat[13] - eye[1]
+				dz := modelMat[14] - eye[2]
+				dc.depth = dx*dx + dy*dy + dz*dz
+				blended = append(blended, dc)
+			} else {
+				opaque = append(opaque, dc)
+			}
+		}
+	}
 
-			// Material
-			bcLoc := gl.GetUniformLocation(a.ShaderProgram, gl.Str("baseColor\x00"))
-			gl.Uniform4f(
-				bcLoc,
-				m.BaseColor[0],
-				m.BaseColor[1],
-				m.BaseColor[2],
-				m.BaseColor[3],
-			)
+	// ----------- Opacos e alpha mask -----------
 
-			utLoc := gl.GetUniformLocation(a.ShaderProgram, gl.Str("useTexture\x00"))
+	gl.Disable(gl.BLEND)
+	gl.DepthMask(true)
+	for _, dc := range opaque {
+		a.drawMesh(dc)
+	}
 
-			if m.HasTexture {
-				gl.Uniform1i(utLoc, 1)
-				gl.ActiveTexture(gl.TEXTURE0)
-				gl.BindTexture(gl.TEXTURE_2D, m.TextureID)
+	// ----------- Transparentes, de trás para frente -----------
 
-				dmLoc := gl.GetUniformLocation(a.ShaderProgram, gl.Str("diffuseMap\x00"))
-				gl.Uniform1i(dmLoc, 0)
-			} else {
-				gl.Uniform1i(utLoc, 0)
-			}
+	sort.Slice(blended, func(i, j int) bool { return blended[i].depth > blended[j].depth })
 
-			gl.BindVertexArray(m.VAO)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.DepthMask(false)
+	for _, dc := range blended {
+		a.drawMesh(dc)
+	}
+	gl.DepthMask(true)
+	gl.Disable(gl.BLEND)
+}
 
-			if m.HasIndices {
-				gl.DrawElements(gl.TRIANGLES, m.IndexCount, gl.UNSIGNED_INT, gl.PtrOffset(0))
-			} else {
-				gl.DrawArrays(gl.TRIANGLES, 0, m.VertexCount)
-			}
+// modelMatrix monta a matriz do Transform de um Model.
+func modelMatrix(entry *model.Model) gmath.Mat4 {
+	t := entry.Transform
+
+	// Usa apenas a rotação definida no Transform
+	rotMat := t.Rotation.Normalize().ToMat4()
+	transMat := gmath.MatTranslate(t.Position)
+	scaleMat := gmath.MatScale(t.Scale.X, t.Scale.Y, t.Scale.Z)
+
+	// Ordem correta: T * R * S
+	return gmath.MatMul(
+		transMat,
+		gmath.MatMul(rotMat, scaleMat),
+	)
+}
+
+// drawMesh configura skinning, instancing, material e estado de culling e
+// desenha a mesh.
+func (a *App) drawMesh(dc drawCall) {
+	m := dc.mesh
+
+	gmath.SetUniformMat4(a.ShaderProgram, "model", dc.modelMat)
+
+	if m.Skin >= 0 {
+		a.joints.bind(a.ShaderProgram, dc.loaded.Skins[m.Skin].Palette)
+		gmath.SetUniformInt(a.ShaderProgram, "useSkinning", 1)
+	} else {
+		gmath.SetUniformInt(a.ShaderProgram, "useSkinning", 0)
+	}
+
+	if m.InstanceCount > 0 {
+		gmath.SetUniformInt(a.ShaderProgram, "useInstancing", 1)
+	} else {
+		gmath.SetUniformInt(a.ShaderProgram, "useInstancing", 0)
+	}
+
+	a.bindMaterial(dc.loaded, m.Material)
+
+	// Culling por material; transforms espelhados invertem o winding
+	if m.Material.DoubleSided {
+		gl.Disable(gl.CULL_FACE)
+	} else {
+		gl.Enable(gl.CULL_FACE)
+		gl.CullFace(gl.BACK)
+	}
+	if gmath.MatDeterminant3(dc.modelMat) < 0 {
+		gl.FrontFace(gl.CW)
+	} else {
+		gl.FrontFace(gl.CCW)
+	}
+
+	// Reenvia a pose dos morph targets se os pesos mudaram
+	m.ApplyMorph()
+
+	gl.BindVertexArray(m.VAO)
+
+	mode := m.GLMode()
+	switch {
+	case m.InstanceCount > 0 && m.HasIndices:
+		gl.DrawElementsInstanced(mode, m.IndexCount, gl.UNSIGNED_INT, gl.PtrOffset(0), m.InstanceCount)
+	case m.InstanceCount > 0:
+		gl.DrawArraysInstanced(mode, 0, m.VertexCount, m.InstanceCount)
+	case m.HasIndices:
+		gl.DrawElements(mode, m.IndexCount, gl.UNSIGNED_INT, gl.PtrOffset(0))
+	default:
+		gl.DrawArrays(mode, 0, m.VertexCount)
+	}
+}
+
+// materialTextures associa cada slot de textura do material a uma texture unit
+// e aos uniforms sampler/flag/conjunto de UV/transformação de UV do fragment
+// shader.
+var materialTextures = []struct {
+	unit      uint32
+	sampler   string
+	flag      string
+	texCoord  string
+	transform string
+	ref       func(*gltfloader.Material) *gltfloader.TextureRef
+}{
+	{0, "baseColorMap", "useBaseColorMap", "baseColorTexCoord", "baseColorUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef { return m.BaseColorTexture }},
+	{1, "metallicRoughnessMap", "useMetallicRoughnessMap", "metallicRoughnessTexCoord", "metallicRoughnessUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef { return m.MetallicRoughnessTexture }},
+	{2, "occlusionMap", "useOcclusionMap", "occlusionTexCoord", "occlusionUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef { return m.OcclusionTexture }},
+	{3, "emissiveMap", "useEmissiveMap", "emissiveTexCoord", "emissiveUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef { return m.EmissiveTexture }},
+	{4, "normalMap", "useNormalMap", "normalTexCoord", "normalUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef { return m.NormalTexture }},
+	{5, "clearcoatMap", "useClearcoatMap", "clearcoatTexCoord", "clearcoatUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef {
+		if m.Clearcoat == nil {
+			return nil
+		}
+		return m.Clearcoat.Texture
+	}},
+	{6, "clearcoatRoughnessMap", "useClearcoatRoughnessMap", "clearcoatRoughnessTexCoord", "clearcoatRoughnessUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef {
+		if m.Clearcoat == nil {
+			return nil
+		}
+		return m.Clearcoat.RoughnessTexture
+	}},
+	{7, "clearcoatNormalMap", "useClearcoatNormalMap", "clearcoatNormalTexCoord", "clearcoatNormalUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef {
+		if m.Clearcoat == nil {
+			return nil
 		}
+		return m.Clearcoat.NormalTexture
+	}},
+	{8, "sheenColorMap", "useSheenColorMap", "sheenColorTexCoord", "sheenColorUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef {
+		if m.Sheen == nil {
+			return nil
+		}
+		return m.Sheen.ColorTexture
+	}},
+	{9, "sheenRoughnessMap", "useSheenRoughnessMap", "sheenRoughnessTexCoord", "sheenRoughnessUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef {
+		if m.Sheen == nil {
+			return nil
+		}
+		return m.Sheen.RoughnessTexture
+	}},
+	{10, "transmissionMap", "useTransmissionMap", "transmissionTexCoord", "transmissionUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef {
+		if m.Transmission == nil {
+			return nil
+		}
+		return m.Transmission.Texture
+	}},
+	{11, "specularMap", "useSpecularMap", "specularTexCoord", "specularUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef {
+		if m.Specular == nil {
+			return nil
+		}
+		return m.Specular.Texture
+	}},
+	{12, "specularColorMap", "useSpecularColorMap", "specularColorTexCoord", "specularColorUVTransform", func(m *gltfloader.Material) *gltfloader.TextureRef {
+		if m.Specular == nil {
+			return nil
+		}
+		return m.Specular.ColorTexture
+	}},
+}
+
+// bindMaterial envia os fatores do material e liga suas texturas.
+func (a *App) bindMaterial(loaded *gltfloader.GLTFModel, mat *gltfloader.Material) {
+	p := a.ShaderProgram
+
+	gmath.SetUniformVec4(p, "baseColorFactor", mat.BaseColorFactor)
+	gmath.SetUniformFloat(p, "metallicFactor", mat.MetallicFactor)
+	gmath.SetUniformFloat(p, "roughnessFactor", mat.RoughnessFactor)
+	gmath.SetUniformFloat(p, "occlusionStrength", mat.OcclusionStrength)
+	gmath.SetUniformFloat(p, "normalScale", mat.NormalScale)
+	gmath.SetUniformVec3(p, "emissiveFactor", mat.EmissiveFactor)
+	gmath.SetUniformFloat(p, "emissiveStrength", mat.EmissiveStrength)
+	if mat.Unlit {
+		gmath.SetUniformInt(p, "unlit", 1)
+	} else {
+		gmath.SetUniformInt(p, "unlit", 0)
+	}
+	gmath.SetUniformInt(p, "alphaMode", int32(mat.AlphaMode))
+	gmath.SetUniformFloat(p, "alphaCutoff", mat.AlphaCutoff)
+	bindMaterialExtensions(p, mat)
+
+	for _, slot := range materialTextures {
+		gmath.SetUniformInt(p, slot.sampler, int32(slot.unit))
+		ref := slot.ref(mat)
+		if id, ok := loaded.Texture(ref); ok {
+			gl.ActiveTexture(gl.TEXTURE0 + slot.unit)
+			gl.BindTexture(gl.TEXTURE_2D, id)
+			gmath.SetUniformInt(p, slot.flag, 1)
+			gmath.SetUniformInt(p, slot.texCoord, int32(ref.TexCoord))
+			gmath.SetUniformMat3(p, slot.transform, ref.UVMatrix())
+		} else {
+			gmath.SetUniformInt(p, slot.flag, 0)
+		}
+	}
+}
+
+// bindMaterialExtensions envia os fatores das extensões PBR. Extensões
+// ausentes recebem os valores neutros, que desligam o lobo no shader.
+func bindMaterialExtensions(p uint32, mat *gltfloader.Material) {
+	if cc := mat.Clearcoat; cc != nil {
+		gmath.SetUniformFloat(p, "clearcoatFactor", cc.Factor)
+		gmath.SetUniformFloat(p, "clearcoatRoughnessFactor", cc.Roughness)
+		gmath.SetUniformFloat(p, "clearcoatNormalScale", cc.NormalScale)
+	} else {
+		gmath.SetUniformFloat(p, "clearcoatFactor", 0)
+		gmath.SetUniformFloat(p, "clearcoatRoughnessFactor", 0)
+		gmath.SetUniformFloat(p, "clearcoatNormalScale", 1)
+	}
+
+	if sh := mat.Sheen; sh != nil {
+		gmath.SetUniformVec3(p, "sheenColorFactor", sh.ColorFactor)
+		gmath.SetUniformFloat(p, "sheenRoughnessFactor", sh.Roughness)
+	} else {
+		gmath.SetUniformVec3(p, "sheenColorFactor", [3]float32{0, 0, 0})
+		gmath.SetUniformFloat(p, "sheenRoughnessFactor", 0)
+	}
+
+	if tr := mat.Transmission; tr != nil {
+		gmath.SetUniformFloat(p, "transmissionFactor", tr.Factor)
+	} else {
+		gmath.SetUniformFloat(p, "transmissionFactor", 0)
+	}
+
+	gmath.SetUniformFloat(p, "ior", mat.IOR)
+
+	if sp := mat.Specular; sp != nil {
+		gmath.SetUniformFloat(p, "specularFactor", sp.Factor)
+		gmath.SetUniformVec3(p, "specularColorFactor", sp.ColorFactor)
+	} else {
+		gmath.SetUniformFloat(p, "specularFactor", 1)
+		gmath.SetUniformVec3(p, "specularColorFactor", [3]float32{1, 1, 1})
 	}
 }
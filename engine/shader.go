@@ -1,24 +1,71 @@
 package engine
 
-import "github.com/go-gl/gl/v4.1-core/gl"
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/joaqu1m/gogl-playground/libs/gltrack"
+)
 
 var vertexShaderSource = `#version 410 core
 layout (location = 0) in vec3 aPos;
 layout (location = 1) in vec3 aNormal;
 layout (location = 2) in vec2 aTexCoord;
+layout (location = 3) in vec4 aJoints;
+layout (location = 4) in vec4 aWeights;
+layout (location = 5) in vec4 aTangent;
+layout (location = 6) in vec4 aColor;
+layout (location = 7) in vec2 aTexCoord1;
+layout (location = 8) in mat4 aInstance; // EXT_mesh_gpu_instancing, locations 8 a 11
 
 uniform mat4 model;
 uniform mat4 view;
 uniform mat4 projection;
+uniform samplerBuffer jointMatrices; // palette de joints, 4 texels (colunas) por matriz
+uniform int useSkinning;
+uniform int useInstancing;
 
 out vec3 vNormal;
 out vec3 vFragPos;
 out vec2 vTexCoord;
+out vec4 vTangent;
+out vec4 vColor;
+out vec2 vTexCoord1;
+
+mat4 jointMatrix(float joint) {
+	int base = int(joint) * 4;
+	return mat4(
+		texelFetch(jointMatrices, base),
+		texelFetch(jointMatrices, base + 1),
+		texelFetch(jointMatrices, base + 2),
+		texelFetch(jointMatrices, base + 3));
+}
 
 void main() {
-	vFragPos = vec3(model * vec4(aPos, 1.0));
-	vNormal = mat3(transpose(inverse(model))) * aNormal;
+	// Cada instância tem seu transform relativo ao nó
+	mat4 world = model;
+	if (useInstancing == 1) {
+		world = model * aInstance;
+	}
+
+	// Linear-blend skinning: mistura as matrizes dos até 4 joints do vértice
+	if (useSkinning == 1) {
+		mat4 skin =
+			aWeights.x * jointMatrix(aJoints.x) +
+			aWeights.y * jointMatrix(aJoints.y) +
+			aWeights.z * jointMatrix(aJoints.z) +
+			aWeights.w * jointMatrix(aJoints.w);
+		world = world * skin;
+	}
+
+	vFragPos = vec3(world * vec4(aPos, 1.0));
+	vNormal = mat3(transpose(inverse(world))) * aNormal;
 	vTexCoord = aTexCoord;
+	vTexCoord1 = aTexCoord1;
+	vColor = aColor;
+
+	// Transforms espelhados invertem a handedness da bitangente
+	float mirror = determinant(mat3(world)) < 0.0 ? -1.0 : 1.0;
+	vTangent = vec4(mat3(world) * aTangent.xyz, aTangent.w * mirror);
+
 	gl_Position = projection * view * vec4(vFragPos, 1.0);
 }` + "\x00"
 
@@ -26,35 +73,342 @@ var fragmentShaderSource = `#version 410 core
 in vec3 vNormal;
 in vec3 vFragPos;
 in vec2 vTexCoord;
+in vec4 vTangent;
+in vec4 vColor;
+in vec2 vTexCoord1;
 
 out vec4 FragColor;
 
-uniform vec3 lightDir;
-uniform sampler2D diffuseMap;
-uniform vec4 baseColor;
-uniform int useTexture;
+uniform vec3 cameraPos;
+
+// Luzes pontuais (KHR_lights_punctual), em espaço de mundo
+const int MAX_LIGHTS = 8;
+const int LIGHT_DIRECTIONAL = 0;
+const int LIGHT_POINT = 1;
+const int LIGHT_SPOT = 2;
+
+struct Light {
+	int type;
+	vec3 color;     // cor * intensidade
+	vec3 position;
+	vec3 direction; // sentido em que a luz viaja
+	float range;    // 0 = sem limite
+	float innerCos;
+	float outerCos;
+};
+
+uniform Light lights[MAX_LIGHTS];
+uniform int lightCount;
+
+// Material metallic-roughness (glTF 2.0)
+uniform vec4 baseColorFactor;
+uniform float metallicFactor;
+uniform float roughnessFactor;
+uniform float occlusionStrength;
+uniform float normalScale;
+uniform vec3 emissiveFactor;
+uniform float emissiveStrength;
+uniform int unlit; // KHR_materials_unlit
+uniform int alphaMode; // 0 = OPAQUE, 1 = MASK, 2 = BLEND
+uniform float alphaCutoff;
+
+// Cada textura tem seu conjunto de UV (TEXCOORD_0 ou TEXCOORD_1) e sua
+// transformação de UV (KHR_texture_transform, identidade sem a extensão)
+uniform sampler2D baseColorMap;
+uniform int useBaseColorMap;
+uniform int baseColorTexCoord;
+uniform mat3 baseColorUVTransform;
+uniform sampler2D metallicRoughnessMap;
+uniform int useMetallicRoughnessMap;
+uniform int metallicRoughnessTexCoord;
+uniform mat3 metallicRoughnessUVTransform;
+uniform sampler2D occlusionMap;
+uniform int useOcclusionMap;
+uniform int occlusionTexCoord;
+uniform mat3 occlusionUVTransform;
+uniform sampler2D emissiveMap;
+uniform int useEmissiveMap;
+uniform int emissiveTexCoord;
+uniform mat3 emissiveUVTransform;
+uniform sampler2D normalMap;
+uniform int useNormalMap;
+uniform int normalTexCoord;
+uniform mat3 normalUVTransform;
+
+// Extensões PBR (clearcoat, sheen, transmission, ior, specular). Os valores
+// padrão enviados sem a extensão reproduzem o material base.
+uniform float clearcoatFactor;
+uniform float clearcoatRoughnessFactor;
+uniform float clearcoatNormalScale;
+uniform vec3 sheenColorFactor;
+uniform float sheenRoughnessFactor;
+uniform float transmissionFactor;
+uniform float ior;
+uniform float specularFactor;
+uniform vec3 specularColorFactor;
+uniform sampler2D clearcoatMap;
+uniform int useClearcoatMap;
+uniform int clearcoatTexCoord;
+uniform mat3 clearcoatUVTransform;
+uniform sampler2D clearcoatRoughnessMap;
+uniform int useClearcoatRoughnessMap;
+uniform int clearcoatRoughnessTexCoord;
+uniform mat3 clearcoatRoughnessUVTransform;
+uniform sampler2D clearcoatNormalMap;
+uniform int useClearcoatNormalMap;
+uniform int clearcoatNormalTexCoord;
+uniform mat3 clearcoatNormalUVTransform;
+uniform sampler2D sheenColorMap;
+uniform int useSheenColorMap;
+uniform int sheenColorTexCoord;
+uniform mat3 sheenColorUVTransform;
+uniform sampler2D sheenRoughnessMap;
+uniform int useSheenRoughnessMap;
+uniform int sheenRoughnessTexCoord;
+uniform mat3 sheenRoughnessUVTransform;
+uniform sampler2D transmissionMap;
+uniform int useTransmissionMap;
+uniform int transmissionTexCoord;
+uniform mat3 transmissionUVTransform;
+uniform sampler2D specularMap;
+uniform int useSpecularMap;
+uniform int specularTexCoord;
+uniform mat3 specularUVTransform;
+uniform sampler2D specularColorMap;
+uniform int useSpecularColorMap;
+uniform int specularColorTexCoord;
+uniform mat3 specularColorUVTransform;
+
+const float PI = 3.14159265359;
+
+// GGX / Trowbridge-Reitz
+float distributionGGX(float NdotH, float alpha) {
+	float a2 = alpha * alpha;
+	float d = NdotH * NdotH * (a2 - 1.0) + 1.0;
+	return a2 / (PI * d * d);
+}
+
+// Smith com Schlick-GGX
+float geometrySmith(float NdotV, float NdotL, float roughness) {
+	float k = (roughness + 1.0) * (roughness + 1.0) / 8.0;
+	float g1v = NdotV / (NdotV * (1.0 - k) + k);
+	float g1l = NdotL / (NdotL * (1.0 - k) + k);
+	return g1v * g1l;
+}
+
+vec3 fresnelSchlick(float cosTheta, vec3 F0, float F90) {
+	return F0 + (F90 - F0) * pow(1.0 - cosTheta, 5.0);
+}
+
+// Charlie (Estevez & Kulla), distribuição do sheen
+float distributionCharlie(float NdotH, float roughness) {
+	float invAlpha = 1.0 / max(roughness * roughness, 0.0001);
+	float sin2h = max(1.0 - NdotH * NdotH, 0.0078125);
+	return (2.0 + invAlpha) * pow(sin2h, invAlpha * 0.5) / (2.0 * PI);
+}
+
+// Visibilidade de Neubelt para o sheen
+float visibilityNeubelt(float NdotL, float NdotV) {
+	return 1.0 / max(4.0 * (NdotL + NdotV - NdotL * NdotV), 0.0001);
+}
+
+// Atenuação por distância recomendada pelo KHR_lights_punctual
+float rangeAttenuation(float dist, float range) {
+	float inv = 1.0 / max(dist * dist, 0.0001);
+	if (range <= 0.0) {
+		return inv;
+	}
+	return clamp(1.0 - pow(dist / range, 4.0), 0.0, 1.0) * inv;
+}
+
+float spotAttenuation(Light light, vec3 L) {
+	float cd = dot(normalize(light.direction), -L);
+	if (cd <= light.outerCos) {
+		return 0.0;
+	}
+	if (cd >= light.innerCos) {
+		return 1.0;
+	}
+	return smoothstep(light.outerCos, light.innerCos, cd);
+}
+
+vec2 texUV(int set, mat3 transform) {
+	vec2 uv = set == 1 ? vTexCoord1 : vTexCoord;
+	return (transform * vec3(uv, 1.0)).xy;
+}
+
+// Normal map em espaço tangente (convenção do glTF: B = w * cross(N, T))
+vec3 perturbNormal(vec3 N, vec3 n, float scale) {
+	vec3 T = normalize(vTangent.xyz - N * dot(N, vTangent.xyz));
+	vec3 B = cross(N, T) * vTangent.w;
+	n.xy *= scale;
+	return normalize(mat3(T, B, N) * n);
+}
 
 void main() {
-	// Cor base: textura ou cor do material
-	vec3 color;
-	if (useTexture == 1) {
-		color = texture(diffuseMap, vTexCoord).rgb * baseColor.rgb;
-	} else {
-		color = baseColor.rgb;
+	// Cor base: fator do material, modulado pela cor de vértice e pela textura
+	vec4 baseColor = baseColorFactor * vColor;
+	if (useBaseColorMap == 1) {
+		baseColor *= texture(baseColorMap, texUV(baseColorTexCoord, baseColorUVTransform));
+	}
+
+	if (alphaMode == 1 && baseColor.a < alphaCutoff) {
+		discard;
+	}
+
+	float alpha = alphaMode == 2 ? baseColor.a : 1.0;
+
+	// Materiais unlit usam a cor base direto, sem luzes nem ambiente
+	if (unlit == 1) {
+		FragColor = vec4(baseColor.rgb, alpha);
+		return;
+	}
+
+	float metallic = metallicFactor;
+	float roughness = roughnessFactor;
+	if (useMetallicRoughnessMap == 1) {
+		vec4 mr = texture(metallicRoughnessMap, texUV(metallicRoughnessTexCoord, metallicRoughnessUVTransform));
+		roughness *= mr.g;
+		metallic *= mr.b;
+	}
+	roughness = clamp(roughness, 0.04, 1.0);
+	metallic = clamp(metallic, 0.0, 1.0);
+
+	float ao = 1.0;
+	if (useOcclusionMap == 1) {
+		ao = 1.0 + occlusionStrength * (texture(occlusionMap, texUV(occlusionTexCoord, occlusionUVTransform)).r - 1.0);
+	}
+
+	vec3 emissive = emissiveFactor * emissiveStrength;
+	if (useEmissiveMap == 1) {
+		emissive *= texture(emissiveMap, texUV(emissiveTexCoord, emissiveUVTransform)).rgb;
+	}
+
+	float clearcoat = clearcoatFactor;
+	if (useClearcoatMap == 1) {
+		clearcoat *= texture(clearcoatMap, texUV(clearcoatTexCoord, clearcoatUVTransform)).r;
+	}
+	float clearcoatRoughness = clearcoatRoughnessFactor;
+	if (useClearcoatRoughnessMap == 1) {
+		clearcoatRoughness *= texture(clearcoatRoughnessMap, texUV(clearcoatRoughnessTexCoord, clearcoatRoughnessUVTransform)).g;
+	}
+	clearcoatRoughness = clamp(clearcoatRoughness, 0.04, 1.0);
+
+	vec3 sheenColor = sheenColorFactor;
+	if (useSheenColorMap == 1) {
+		sheenColor *= texture(sheenColorMap, texUV(sheenColorTexCoord, sheenColorUVTransform)).rgb;
+	}
+	float sheenRoughness = sheenRoughnessFactor;
+	if (useSheenRoughnessMap == 1) {
+		sheenRoughness *= texture(sheenRoughnessMap, texUV(sheenRoughnessTexCoord, sheenRoughnessUVTransform)).a;
+	}
+	sheenRoughness = clamp(sheenRoughness, 0.07, 1.0);
+
+	float transmission = transmissionFactor;
+	if (useTransmissionMap == 1) {
+		transmission *= texture(transmissionMap, texUV(transmissionTexCoord, transmissionUVTransform)).r;
+	}
+
+	float specularWeight = specularFactor;
+	if (useSpecularMap == 1) {
+		specularWeight *= texture(specularMap, texUV(specularTexCoord, specularUVTransform)).a;
+	}
+	vec3 specularColor = specularColorFactor;
+	if (useSpecularColorMap == 1) {
+		specularColor *= texture(specularColorMap, texUV(specularColorTexCoord, specularColorUVTransform)).rgb;
+	}
+
+	vec3 Ng = normalize(vNormal);
+	bool hasTangent = dot(vTangent.xyz, vTangent.xyz) > 0.0;
+
+	vec3 N = Ng;
+	if (useNormalMap == 1 && hasTangent) {
+		vec3 n = texture(normalMap, texUV(normalTexCoord, normalUVTransform)).rgb * 2.0 - 1.0;
+		N = perturbNormal(Ng, n, normalScale);
+	}
+
+	// O clearcoat tem normal própria; sem mapa usa a normal geométrica
+	vec3 Nc = Ng;
+	if (useClearcoatNormalMap == 1 && hasTangent) {
+		vec3 n = texture(clearcoatNormalMap, texUV(clearcoatNormalTexCoord, clearcoatNormalUVTransform)).rgb * 2.0 - 1.0;
+		Nc = perturbNormal(Ng, n, clearcoatNormalScale);
+	}
+
+	// Faces de trás (materiais double-sided) usam a normal invertida
+	if (!gl_FrontFacing) {
+		N = -N;
+		Nc = -Nc;
+	}
+	vec3 V = normalize(cameraPos - vFragPos);
+	float NdotV = max(dot(N, V), 0.0001);
+	float NcdotV = max(dot(Nc, V), 0.0001);
+
+	// F0 dielétrico vem do IOR (0.04 para 1.5), tingido pelo KHR_materials_specular
+	float f0 = pow((ior - 1.0) / (ior + 1.0), 2.0);
+	vec3 dielectricF0 = min(f0 * specularColor, vec3(1.0)) * specularWeight;
+	vec3 F0 = mix(dielectricF0, baseColor.rgb, metallic);
+	float F90 = mix(specularWeight, 1.0, metallic);
+
+	// Atenuação do material base pelo clearcoat, vista da câmera
+	vec3 clearcoatFresnel = clearcoat * fresnelSchlick(NcdotV, vec3(0.04), 1.0);
+
+	vec3 direct = vec3(0.0);
+
+	for (int i = 0; i < lightCount && i < MAX_LIGHTS; i++) {
+		Light light = lights[i];
+
+		vec3 L;
+		vec3 radiance = light.color;
+		if (light.type == LIGHT_DIRECTIONAL) {
+			L = normalize(-light.direction);
+		} else {
+			vec3 toLight = light.position - vFragPos;
+			float dist = length(toLight);
+			L = toLight / max(dist, 0.0001);
+			radiance *= rangeAttenuation(dist, light.range);
+			if (light.type == LIGHT_SPOT) {
+				radiance *= spotAttenuation(light, L);
+			}
+		}
+
+		vec3 H = normalize(V + L);
+		float NdotL = max(dot(N, L), 0.0);
+		float NdotH = max(dot(N, H), 0.0);
+		float VdotH = max(dot(V, H), 0.0);
+
+		// Cook-Torrance: Lambert difuso + GGX especular
+		vec3 F = fresnelSchlick(VdotH, F0, F90);
+		float D = distributionGGX(NdotH, roughness * roughness);
+		float G = geometrySmith(NdotV, NdotL, roughness);
+		vec3 specular = D * G * F / max(4.0 * NdotV * NdotL, 0.0001);
+		vec3 kd = (1.0 - F) * (1.0 - metallic);
+		vec3 diffuse = kd * baseColor.rgb / PI;
+
+		// Transmissão sem buffer da cena: aproxima a superfície como fina e
+		// deixa passar a luz que chega por trás, tingida pela cor base
+		float backNdotL = max(dot(-N, L), 0.0);
+		vec3 base = mix(diffuse * NdotL, diffuse * backNdotL, transmission) + specular * NdotL;
+
+		// Sheen: lobo Charlie somado ao material base
+		vec3 sheen = sheenColor * distributionCharlie(NdotH, sheenRoughness) * visibilityNeubelt(NdotL, NdotV) * NdotL;
+
+		// Clearcoat: segundo lobo GGX dielétrico sobre tudo
+		float NcdotL = max(dot(Nc, L), 0.0);
+		float NcdotH = max(dot(Nc, H), 0.0);
+		vec3 Fc = fresnelSchlick(VdotH, vec3(0.04), 1.0);
+		float Dc = distributionGGX(NcdotH, clearcoatRoughness * clearcoatRoughness);
+		float Gc = geometrySmith(NcdotV, NcdotL, clearcoatRoughness);
+		vec3 coat = clearcoat * Dc * Gc * Fc / max(4.0 * NcdotV * NcdotL, 0.0001) * NcdotL;
+
+		direct += ((base + sheen) * (1.0 - clearcoatFresnel) + coat) * radiance;
 	}
 
 	// Ambient
 	float ambientStrength = 0.2;
-	vec3 ambient = ambientStrength * vec3(1.0);
-
-	// Diffuse
-	vec3 norm = normalize(vNormal);
-	vec3 light = normalize(-lightDir);
-	float diff = max(dot(norm, light), 0.0);
-	vec3 diffuse = diff * vec3(1.0);
+	vec3 ambient = ambientStrength * baseColor.rgb * ao;
 
-	vec3 result = (ambient + diffuse) * color;
-	FragColor = vec4(result, baseColor.a);
+	vec3 result = ambient + direct + emissive;
+	FragColor = vec4(result, alpha);
 }` + "\x00"
 
 func createShaderProgram() uint32 {
@@ -62,6 +416,7 @@ func createShaderProgram() uint32 {
 	fragmentShader := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
 
 	program := gl.CreateProgram()
+	gltrack.Track(gltrack.Program, program, "engine: shader principal")
 	gl.AttachShader(program, vertexShader)
 	gl.AttachShader(program, fragmentShader)
 	gl.LinkProgram(program)
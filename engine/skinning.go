@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/joaqu1m/gogl-playground/gmath"
+	"github.com/joaqu1m/gogl-playground/libs/gltrack"
+)
+
+// jointPaletteUnit é a texture unit da palette de joints; as units abaixo
+// dela são dos slots de material (ver materialTextures).
+const jointPaletteUnit = 13
+
+// jointPalette guarda a matrix palette da skin sendo desenhada num texture
+// buffer, lido no vertex shader com texelFetch. Ao contrário de um array de
+// uniforms, não há limite prático de joints: cada matriz ocupa 4 texels
+// RGBA32F e o GL 4.1 garante pelo menos 65536 texels (16384 joints).
+type jointPalette struct {
+	buffer  uint32
+	texture uint32
+}
+
+func newJointPalette() jointPalette {
+	var p jointPalette
+	gl.GenBuffers(1, &p.buffer)
+	gl.GenTextures(1, &p.texture)
+	gltrack.Track(gltrack.Buffer, p.buffer, "engine: palette de joints")
+	gltrack.Track(gltrack.Texture, p.texture, "engine: palette de joints")
+
+	gl.BindBuffer(gl.TEXTURE_BUFFER, p.buffer)
+	gl.BufferData(gl.TEXTURE_BUFFER, 16*4, nil, gl.STREAM_DRAW)
+	gl.BindTexture(gl.TEXTURE_BUFFER, p.texture)
+	gl.TexBuffer(gl.TEXTURE_BUFFER, gl.RGBA32F, p.buffer)
+	gl.BindTexture(gl.TEXTURE_BUFFER, 0)
+	gl.BindBuffer(gl.TEXTURE_BUFFER, 0)
+	return p
+}
+
+// bind envia a palette e a liga ao sampler jointMatrices do shader.
+func (p jointPalette) bind(program uint32, palette [][16]float32) {
+	if len(palette) > 0 {
+		gl.BindBuffer(gl.TEXTURE_BUFFER, p.buffer)
+		gl.BufferData(gl.TEXTURE_BUFFER, len(palette)*16*4, gl.Ptr(&palette[0][0]), gl.STREAM_DRAW)
+		gl.BindBuffer(gl.TEXTURE_BUFFER, 0)
+	}
+	gl.ActiveTexture(gl.TEXTURE0 + jointPaletteUnit)
+	gl.BindTexture(gl.TEXTURE_BUFFER, p.texture)
+	gmath.SetUniformInt(program, "jointMatrices", jointPaletteUnit)
+}
+
+func (p *jointPalette) release() {
+	if p.texture != 0 {
+		gl.DeleteTextures(1, &p.texture)
+		gltrack.Untrack(gltrack.Texture, p.texture)
+		p.texture = 0
+	}
+	if p.buffer != 0 {
+		gl.DeleteBuffers(1, &p.buffer)
+		gltrack.Untrack(gltrack.Buffer, p.buffer)
+		p.buffer = 0
+	}
+}
@@ -110,6 +110,32 @@ func MatPerspective(fovy, aspect, near, far float32) Mat4 {
 	}
 }
 
+// MatPerspectiveInfinite é a perspectiva sem far plane usada por câmeras
+// glTF sem zfar.
+func MatPerspectiveInfinite(fovy, aspect, near float32) Mat4 {
+	f := float32(1.0 / smath.Tan(float64(fovy/2.0)))
+
+	return Mat4{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, -1, -1,
+		0, 0, -2 * near, 0,
+	}
+}
+
+// MatOrthographic monta a projeção ortográfica de uma câmera glTF, onde
+// xmag/ymag são metade da largura/altura do volume de visão.
+func MatOrthographic(xmag, ymag, near, far float32) Mat4 {
+	nf := near - far
+
+	return Mat4{
+		1 / xmag, 0, 0, 0,
+		0, 1 / ymag, 0, 0,
+		0, 0, 2 / nf, 0,
+		0, 0, (far + near) / nf, 1,
+	}
+}
+
 func MatLookAt(eye, center, up [3]float32) Mat4 {
 	f := vecNormalize(vecSub(center, eye))
 	s := vecNormalize(vecCross(f, up))
@@ -154,11 +180,73 @@ func SetUniformMat4(program uint32, name string, m Mat4) {
 	gl.UniformMatrix4fv(loc, 1, false, &m[0])
 }
 
+// SetUniformMat3 envia uma mat3 column-major.
+func SetUniformMat3(program uint32, name string, m [9]float32) {
+	loc := gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+	gl.UniformMatrix3fv(loc, 1, false, &m[0])
+}
+
 func SetUniformVec3(program uint32, name string, v [3]float32) {
 	loc := gl.GetUniformLocation(program, gl.Str(name+"\x00"))
 	gl.Uniform3f(loc, v[0], v[1], v[2])
 }
 
+func SetUniformVec4(program uint32, name string, v [4]float32) {
+	loc := gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+	gl.Uniform4f(loc, v[0], v[1], v[2], v[3])
+}
+
+func SetUniformFloat(program uint32, name string, v float32) {
+	loc := gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+	gl.Uniform1f(loc, v)
+}
+
+func SetUniformInt(program uint32, name string, v int32) {
+	loc := gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+	gl.Uniform1i(loc, v)
+}
+
+// MatInverse retorna a inversa de m, ou a identidade se m for singular.
+func MatInverse(m Mat4) Mat4 {
+	var inv Mat4
+
+	inv[0] = m[5]*m[10]*m[15] - m[5]*m[11]*m[14] - m[9]*m[6]*m[15] + m[9]*m[7]*m[14] + m[13]*m[6]*m[11] - m[13]*m[7]*m[10]
+	inv[4] = -m[4]*m[10]*m[15] + m[4]*m[11]*m[14] + m[8]*m[6]*m[15] - m[8]*m[7]*m[14] - m[12]*m[6]*m[11] + m[12]*m[7]*m[10]
+	inv[8] = m[4]*m[9]*m[15] - m[4]*m[11]*m[13] - m[8]*m[5]*m[15] + m[8]*m[7]*m[13] + m[12]*m[5]*m[11] - m[12]*m[7]*m[9]
+	inv[12] = -m[4]*m[9]*m[14] + m[4]*m[10]*m[13] + m[8]*m[5]*m[14] - m[8]*m[6]*m[13] - m[12]*m[5]*m[10] + m[12]*m[6]*m[9]
+	inv[1] = -m[1]*m[10]*m[15] + m[1]*m[11]*m[14] + m[9]*m[2]*m[15] - m[9]*m[3]*m[14] - m[13]*m[2]*m[11] + m[13]*m[3]*m[10]
+	inv[5] = m[0]*m[10]*m[15] - m[0]*m[11]*m[14] - m[8]*m[2]*m[15] + m[8]*m[3]*m[14] + m[12]*m[2]*m[11] - m[12]*m[3]*m[10]
+	inv[9] = -m[0]*m[9]*m[15] + m[0]*m[11]*m[13] + m[8]*m[1]*m[15] - m[8]*m[3]*m[13] - m[12]*m[1]*m[11] + m[12]*m[3]*m[9]
+	inv[13] = m[0]*m[9]*m[14] - m[0]*m[10]*m[13] - m[8]*m[1]*m[14] + m[8]*m[2]*m[13] + m[12]*m[1]*m[10] - m[12]*m[2]*m[9]
+	inv[2] = m[1]*m[6]*m[15] - m[1]*m[7]*m[14] - m[5]*m[2]*m[15] + m[5]*m[3]*m[14] + m[13]*m[2]*m[7] - m[13]*m[3]*m[6]
+	inv[6] = -m[0]*m[6]*m[15] + m[0]*m[7]*m[14] + m[4]*m[2]*m[15] - m[4]*m[3]*m[14] - m[12]*m[2]*m[7] + m[12]*m[3]*m[6]
+	inv[10] = m[0]*m[5]*m[15] - m[0]*m[7]*m[13] - m[4]*m[1]*m[15] + m[4]*m[3]*m[13] + m[12]*m[1]*m[7] - m[12]*m[3]*m[5]
+	inv[14] = -m[0]*m[5]*m[14] + m[0]*m[6]*m[13] + m[4]*m[1]*m[14] - m[4]*m[2]*m[13] - m[12]*m[1]*m[6] + m[12]*m[2]*m[5]
+	inv[3] = -m[1]*m[6]*m[11] + m[1]*m[7]*m[10] + m[5]*m[2]*m[11] - m[5]*m[3]*m[10] - m[9]*m[2]*m[7] + m[9]*m[3]*m[6]
+	inv[7] = m[0]*m[6]*m[11] - m[0]*m[7]*m[10] - m[4]*m[2]*m[11] + m[4]*m[3]*m[10] + m[8]*m[2]*m[7] - m[8]*m[3]*m[6]
+	inv[11] = -m[0]*m[5]*m[11] + m[0]*m[7]*m[9] + m[4]*m[1]*m[11] - m[4]*m[3]*m[9] - m[8]*m[1]*m[7] + m[8]*m[3]*m[5]
+	inv[15] = m[0]*m[5]*m[10] - m[0]*m[6]*m[9] - m[4]*m[1]*m[10] + m[4]*m[2]*m[9] + m[8]*m[1]*m[6] - m[8]*m[2]*m[5]
+
+	det := m[0]*inv[0] + m[1]*inv[4] + m[2]*inv[8] + m[3]*inv[12]
+	if det == 0 {
+		return MatIdentity()
+	}
+
+	for i := range inv {
+		inv[i] /= det
+	}
+
+	return inv
+}
+
+// MatDeterminant3 retorna o determinante da parte 3x3 (rotação/escala) de m.
+// Um valor negativo indica que o transform espelha a geometria.
+func MatDeterminant3(m Mat4) float32 {
+	return m[0]*(m[5]*m[10]-m[9]*m[6]) -
+		m[4]*(m[1]*m[10]-m[9]*m[2]) +
+		m[8]*(m[1]*m[6]-m[5]*m[2])
+}
+
 func MatTranslate(v Vec3) Mat4 {
 	return Mat4{
 		1, 0, 0, 0,
@@ -0,0 +1,446 @@
+package gltfloader
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// Interpolation é o modo de interpolação de um AnimationSampler.
+type Interpolation int
+
+const (
+	InterpolationLinear Interpolation = iota
+	InterpolationStep
+	InterpolationCubicSpline
+)
+
+// AnimationPath é a propriedade do nó animada por um canal.
+type AnimationPath int
+
+const (
+	PathTranslation AnimationPath = iota
+	PathRotation
+	PathScale
+	PathWeights
+)
+
+// AnimationSampler guarda os keyframes de um sampler glTF.
+// Output é achatado: Components floats por keyframe, ou 3*Components no
+// modo CUBICSPLINE (in-tangent, valor, out-tangent).
+type AnimationSampler struct {
+	Interpolation Interpolation
+	Input         []float32
+	Output        []float32
+	Components    int
+}
+
+// AnimationChannel liga um sampler a uma propriedade de um nó.
+type AnimationChannel struct {
+	Node    int
+	Path    AnimationPath
+	Sampler int
+}
+
+// Animation é um clip glTF. Duration é o maior tempo entre os inputs.
+type Animation struct {
+	Name     string
+	Channels []AnimationChannel
+	Samplers []*AnimationSampler
+	Duration float32
+}
+
+// decodeAnimations lê todos os clips do documento. Canais com path não
+// suportado são ignorados. Samplers mantém os índices do documento; canais
+// de weights podem apontar para cópias adicionadas no fim.
+func decodeAnimations(doc *gltf.Document) ([]*Animation, error) {
+	anims := make([]*Animation, 0, len(doc.Animations))
+	for i, a := range doc.Animations {
+		anim := &Animation{Name: a.Name}
+		if anim.Name == "" {
+			anim.Name = fmt.Sprintf("animation_%d", i)
+		}
+
+		for j, s := range a.Samplers {
+			sampler, err := decodeAnimationSampler(doc, s)
+			if err != nil {
+				return nil, fmt.Errorf("animação %q, sampler %d: %w", anim.Name, j, err)
+			}
+			if n := len(sampler.Input); n > 0 && sampler.Input[n-1] > anim.Duration {
+				anim.Duration = sampler.Input[n-1]
+			}
+			anim.Samplers = append(anim.Samplers, sampler)
+		}
+
+		for _, c := range a.Channels {
+			if c.Target.Node == nil {
+				continue
+			}
+			if *c.Target.Node < 0 || *c.Target.Node >= len(doc.Nodes) {
+				return nil, fmt.Errorf("animação %q: node %d fora do range", anim.Name, *c.Target.Node)
+			}
+			if c.Sampler < 0 || c.Sampler >= len(anim.Samplers) {
+				return nil, fmt.Errorf("animação %q: sampler %d fora do range", anim.Name, c.Sampler)
+			}
+
+			samplerIdx := c.Sampler
+			var path AnimationPath
+			switch c.Target.Path {
+			case gltf.TRSTranslation:
+				path = PathTranslation
+			case gltf.TRSRotation:
+				path = PathRotation
+			case gltf.TRSScale:
+				path = PathScale
+			case gltf.TRSWeights:
+				path = PathWeights
+				// O output de weights é escalar: cada keyframe tem um peso por
+				// morph target da mesh do nó.
+				node := doc.Nodes[*c.Target.Node]
+				if node.Mesh == nil || *node.Mesh < 0 || *node.Mesh >= len(doc.Meshes) {
+					continue
+				}
+				mesh := doc.Meshes[*node.Mesh]
+				if len(mesh.Primitives) == 0 || len(mesh.Primitives[0].Targets) == 0 {
+					continue
+				}
+				// O mesmo sampler pode servir nós com quantidades diferentes
+				// de targets, então cada canal ganha sua própria cópia com o
+				// Components certo (Input/Output continuam compartilhados).
+				sampler := anim.Samplers[c.Sampler]
+				if targets := len(mesh.Primitives[0].Targets); sampler.Components != targets {
+					cp := *sampler
+					cp.Components = targets
+					sampler = &cp
+					anim.Samplers = append(anim.Samplers, sampler)
+					samplerIdx = len(anim.Samplers) - 1
+				}
+				valuesPerKey := sampler.Components
+				if sampler.Interpolation == InterpolationCubicSpline {
+					valuesPerKey *= 3
+				}
+				if len(sampler.Output) < len(sampler.Input)*valuesPerKey {
+					return nil, fmt.Errorf("animação %q: output de weights com %d valores para %d keyframes", anim.Name, len(sampler.Output), len(sampler.Input))
+				}
+			default:
+				continue
+			}
+
+			anim.Channels = append(anim.Channels, AnimationChannel{
+				Node:    *c.Target.Node,
+				Path:    path,
+				Sampler: samplerIdx,
+			})
+		}
+
+		anims = append(anims, anim)
+	}
+	return anims, nil
+}
+
+func decodeAnimationSampler(doc *gltf.Document, s *gltf.AnimationSampler) (*AnimationSampler, error) {
+	if s.Input < 0 || s.Input >= len(doc.Accessors) || s.Output < 0 || s.Output >= len(doc.Accessors) {
+		return nil, fmt.Errorf("accessor fora do range")
+	}
+
+	input, _, err := readFloats(doc, doc.Accessors[s.Input])
+	if err != nil {
+		return nil, fmt.Errorf("erro lendo input: %w", err)
+	}
+	output, components, err := readFloats(doc, doc.Accessors[s.Output])
+	if err != nil {
+		return nil, fmt.Errorf("erro lendo output: %w", err)
+	}
+
+	sampler := &AnimationSampler{
+		Input:      input,
+		Output:     output,
+		Components: components,
+	}
+	switch s.Interpolation {
+	case gltf.InterpolationStep:
+		sampler.Interpolation = InterpolationStep
+	case gltf.InterpolationCubicSpline:
+		sampler.Interpolation = InterpolationCubicSpline
+	default:
+		sampler.Interpolation = InterpolationLinear
+	}
+
+	valuesPerKey := components
+	if sampler.Interpolation == InterpolationCubicSpline {
+		valuesPerKey *= 3
+	}
+	if len(output) < len(input)*valuesPerKey {
+		return nil, fmt.Errorf("output com %d valores para %d keyframes", len(output), len(input))
+	}
+	return sampler, nil
+}
+
+// readFloats lê um accessor numérico como floats achatados, desnormalizando
+// tipos inteiros. Retorna também o número de componentes por elemento.
+func readFloats(doc *gltf.Document, acr *gltf.Accessor) ([]float32, int, error) {
+	return readAccessorFloats(doc, acr, true)
+}
+
+// readAccessorFloats é como readFloats, mas só desnormaliza inteiros se
+// normalize for true; senão converte o valor direto, como em atributos
+// quantizados não normalizados.
+func readAccessorFloats(doc *gltf.Document, acr *gltf.Accessor, normalize bool) ([]float32, int, error) {
+	data, err := modeler.ReadAccessor(doc, acr, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	i8 := component(normalize, gltf.DenormalizeByte)
+	u8 := component(normalize, gltf.DenormalizeUbyte)
+	i16 := component(normalize, gltf.DenormalizeShort)
+	u16 := component(normalize, gltf.DenormalizeUshort)
+
+	switch v := data.(type) {
+	case []float32:
+		return append([]float32(nil), v...), 1, nil
+	case [][2]float32:
+		out := make([]float32, 0, len(v)*2)
+		for _, e := range v {
+			out = append(out, e[:]...)
+		}
+		return out, 2, nil
+	case [][3]float32:
+		out := make([]float32, 0, len(v)*3)
+		for _, e := range v {
+			out = append(out, e[:]...)
+		}
+		return out, 3, nil
+	case [][4]float32:
+		out := make([]float32, 0, len(v)*4)
+		for _, e := range v {
+			out = append(out, e[:]...)
+		}
+		return out, 4, nil
+	case []int8:
+		return convert(v, i8), 1, nil
+	case []uint8:
+		return convert(v, u8), 1, nil
+	case []int16:
+		return convert(v, i16), 1, nil
+	case []uint16:
+		return convert(v, u16), 1, nil
+	case [][2]int8:
+		return flattenN(v, i8), 2, nil
+	case [][2]uint8:
+		return flattenN(v, u8), 2, nil
+	case [][2]int16:
+		return flattenN(v, i16), 2, nil
+	case [][2]uint16:
+		return flattenN(v, u16), 2, nil
+	case [][3]int8:
+		return flattenN(v, i8), 3, nil
+	case [][3]uint8:
+		return flattenN(v, u8), 3, nil
+	case [][3]int16:
+		return flattenN(v, i16), 3, nil
+	case [][3]uint16:
+		return flattenN(v, u16), 3, nil
+	case [][4]int8:
+		return flatten4(v, i8), 4, nil
+	case [][4]uint8:
+		return flatten4(v, u8), 4, nil
+	case [][4]int16:
+		return flatten4(v, i16), 4, nil
+	case [][4]uint16:
+		return flatten4(v, u16), 4, nil
+	}
+	return nil, 0, fmt.Errorf("tipo de accessor não suportado: %T", data)
+}
+
+// component escolhe a conversão de um componente inteiro: a desnormalização
+// do spec, ou o valor direto quando normalize é false.
+func component[T int8 | uint8 | int16 | uint16](normalize bool, denorm func(T) float32) func(T) float32 {
+	if normalize {
+		return denorm
+	}
+	return func(v T) float32 { return float32(v) }
+}
+
+func convert[T any](in []T, f func(T) float32) []float32 {
+	out := make([]float32, len(in))
+	for i, v := range in {
+		out[i] = f(v)
+	}
+	return out
+}
+
+func flatten4[T any](in [][4]T, f func(T) float32) []float32 {
+	out := make([]float32, 0, len(in)*4)
+	for _, v := range in {
+		out = append(out, f(v[0]), f(v[1]), f(v[2]), f(v[3]))
+	}
+	return out
+}
+
+// flattenN é como flatten4 para elementos de 2 ou 3 componentes.
+func flattenN[E [2]T | [3]T, T any](in []E, f func(T) float32) []float32 {
+	var out []float32
+	for _, v := range in {
+		for i := 0; i < len(v); i++ {
+			out = append(out, f(v[i]))
+		}
+	}
+	return out
+}
+
+// Sample avalia o sampler no tempo t e escreve Components floats em out.
+// Fora do intervalo dos keyframes o valor é o do keyframe mais próximo.
+// Para PathRotation o LINEAR usa slerp e o resultado é normalizado.
+func (s *AnimationSampler) Sample(t float32, path AnimationPath, out []float32) {
+	n := len(s.Input)
+	c := s.Components
+	if n == 0 {
+		return
+	}
+
+	stride := c
+	valueOffset := 0
+	if s.Interpolation == InterpolationCubicSpline {
+		stride = 3 * c
+		valueOffset = c
+	}
+	value := func(k int) []float32 {
+		start := k*stride + valueOffset
+		return s.Output[start : start+c]
+	}
+
+	if n == 1 || t <= s.Input[0] {
+		copy(out, value(0))
+		return
+	}
+	if t >= s.Input[n-1] {
+		copy(out, value(n-1))
+		return
+	}
+
+	// Primeiro keyframe com tempo > t; o intervalo é [k, k+1]
+	k := sort.Search(n, func(i int) bool { return s.Input[i] > t }) - 1
+	t0, t1 := s.Input[k], s.Input[k+1]
+	td := t1 - t0
+	u := float32(0)
+	if td > 0 {
+		u = (t - t0) / td
+	}
+
+	switch s.Interpolation {
+	case InterpolationStep:
+		copy(out, value(k))
+
+	case InterpolationCubicSpline:
+		v0 := value(k)
+		b0 := s.Output[k*stride+2*c : k*stride+3*c]   // out-tangent de k
+		a1 := s.Output[(k+1)*stride : (k+1)*stride+c] // in-tangent de k+1
+		v1 := value(k + 1)
+		u2 := u * u
+		u3 := u2 * u
+		h00 := 2*u3 - 3*u2 + 1
+		h10 := u3 - 2*u2 + u
+		h01 := -2*u3 + 3*u2
+		h11 := u3 - u2
+		for i := 0; i < c; i++ {
+			out[i] = h00*v0[i] + h10*td*b0[i] + h01*v1[i] + h11*td*a1[i]
+		}
+		if path == PathRotation {
+			normalizeQuat(out)
+		}
+
+	default:
+		v0, v1 := value(k), value(k+1)
+		if path == PathRotation {
+			slerp(v0, v1, u, out)
+			return
+		}
+		for i := 0; i < c; i++ {
+			out[i] = v0[i] + (v1[i]-v0[i])*u
+		}
+	}
+}
+
+// ApplyAnimation avalia todos os canais do clip no tempo t, escreve o
+// resultado nos nós e recalcula os transforms de mundo.
+func (m *GLTFModel) ApplyAnimation(anim *Animation, t float32) {
+	for _, ch := range anim.Channels {
+		if ch.Node >= len(m.Nodes) {
+			continue
+		}
+		sampler := anim.Samplers[ch.Sampler]
+		node := m.Nodes[ch.Node]
+		if len(m.animScratch) < max(4, sampler.Components) {
+			m.animScratch = make([]float32, max(4, sampler.Components))
+		}
+		buf := m.animScratch
+		sampler.Sample(t, ch.Path, buf)
+
+		switch ch.Path {
+		case PathTranslation:
+			node.SetTranslation([3]float32{buf[0], buf[1], buf[2]})
+		case PathRotation:
+			node.SetRotation([4]float32{buf[0], buf[1], buf[2], buf[3]})
+		case PathScale:
+			node.SetScale([3]float32{buf[0], buf[1], buf[2]})
+		case PathWeights:
+			for _, mesh := range m.Meshes {
+				if mesh.Node == ch.Node {
+					mesh.SetMorphWeights(buf[:sampler.Components])
+				}
+			}
+		}
+	}
+	m.UpdateWorldTransforms()
+}
+
+// Animation procura um clip pelo nome. Retorna nil se não existir.
+func (m *GLTFModel) Animation(name string) *Animation {
+	for _, a := range m.Animations {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// slerp interpola dois quaternions xyzw pelo caminho mais curto.
+func slerp(a, b []float32, u float32, out []float32) {
+	dot := a[0]*b[0] + a[1]*b[1] + a[2]*b[2] + a[3]*b[3]
+	sign := float32(1)
+	if dot < 0 {
+		dot = -dot
+		sign = -1
+	}
+
+	var wa, wb float32
+	if dot > 0.9995 {
+		// Quaternions quase iguais: lerp evita divisão por sin ~ 0
+		wa = 1 - u
+		wb = u * sign
+	} else {
+		theta := math.Acos(float64(dot))
+		sinTheta := math.Sin(theta)
+		wa = float32(math.Sin((1-float64(u))*theta) / sinTheta)
+		wb = float32(math.Sin(float64(u)*theta)/sinTheta) * sign
+	}
+
+	for i := 0; i < 4; i++ {
+		out[i] = wa*a[i] + wb*b[i]
+	}
+	normalizeQuat(out)
+}
+
+func normalizeQuat(q []float32) {
+	l := float32(math.Sqrt(float64(q[0]*q[0] + q[1]*q[1] + q[2]*q[2] + q[3]*q[3])))
+	if l > 0 {
+		q[0] /= l
+		q[1] /= l
+		q[2] /= l
+		q[3] /= l
+	}
+}
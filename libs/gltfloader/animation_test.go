@@ -0,0 +1,162 @@
+package gltfloader
+
+import (
+	"math"
+	"testing"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+func nearFloats(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > 1e-5 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSamplerLinearAndStep(t *testing.T) {
+	s := &AnimationSampler{
+		Input:      []float32{0, 1},
+		Output:     []float32{0, 0, 0, 2, 4, 6},
+		Components: 3,
+	}
+	tests := []struct {
+		name   string
+		interp Interpolation
+		t      float32
+		want   []float32
+	}{
+		{"linear no meio", InterpolationLinear, 0.5, []float32{1, 2, 3}},
+		{"linear antes do início", InterpolationLinear, -1, []float32{0, 0, 0}},
+		{"linear depois do fim", InterpolationLinear, 2, []float32{2, 4, 6}},
+		{"step segura o keyframe", InterpolationStep, 0.99, []float32{0, 0, 0}},
+		{"step no keyframe seguinte", InterpolationStep, 1, []float32{2, 4, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.Interpolation = tt.interp
+			out := make([]float32, 3)
+			s.Sample(tt.t, PathTranslation, out)
+			if !nearFloats(out, tt.want) {
+				t.Errorf("Sample(%v) = %v, want %v", tt.t, out, tt.want)
+			}
+		})
+	}
+}
+
+func TestSamplerRotationSlerp(t *testing.T) {
+	// Identidade até 90° em Z; no meio, 45° em Z
+	s45, c45 := float32(math.Sin(math.Pi/4)), float32(math.Cos(math.Pi/4))
+	want := []float32{0, 0, float32(math.Sin(math.Pi / 8)), float32(math.Cos(math.Pi / 8))}
+
+	for _, sign := range []float32{1, -1} {
+		// -q é a mesma rotação: o slerp tem que ir pelo caminho mais curto
+		s := &AnimationSampler{
+			Input:      []float32{0, 1},
+			Output:     []float32{0, 0, 0, 1, 0, 0, sign * s45, sign * c45},
+			Components: 4,
+		}
+		out := make([]float32, 4)
+		s.Sample(0.5, PathRotation, out)
+		if !nearFloats(out, want) {
+			t.Errorf("sinal %v: got %v, want %v", sign, out, want)
+		}
+	}
+}
+
+func TestSamplerCubicSpline(t *testing.T) {
+	// Keyframes [in-tangent, valor, out-tangent]: valores 0 e 0, tangente de
+	// saída 1 no primeiro. No meio de um intervalo de 2s:
+	// h10(0.5) * td * b0 = 0.125 * 2 * 1
+	s := &AnimationSampler{
+		Interpolation: InterpolationCubicSpline,
+		Input:         []float32{0, 2},
+		Output:        []float32{0, 0, 1, 0, 0, 0},
+		Components:    1,
+	}
+	out := make([]float32, 1)
+	s.Sample(1, PathWeights, out)
+	if !nearFloats(out, []float32{0.25}) {
+		t.Errorf("got %v, want [0.25]", out)
+	}
+
+	// Fora do intervalo vale o valor do keyframe, não a tangente
+	s.Sample(5, PathWeights, out)
+	if out[0] != 0 {
+		t.Errorf("depois do fim: got %v, want 0", out[0])
+	}
+}
+
+func TestWeightsChannelsDoNotShareComponents(t *testing.T) {
+	doc := gltf.NewDocument()
+	pos := modeler.WritePosition(doc, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}})
+	meshWithTargets := func(n int) *gltf.Mesh {
+		prim := &gltf.Primitive{Attributes: gltf.PrimitiveAttributes{gltf.POSITION: pos}}
+		for i := 0; i < n; i++ {
+			prim.Targets = append(prim.Targets, gltf.PrimitiveAttributes{gltf.POSITION: pos})
+		}
+		return &gltf.Mesh{Primitives: []*gltf.Primitive{prim}}
+	}
+	doc.Meshes = []*gltf.Mesh{meshWithTargets(2), meshWithTargets(3)}
+	a, b := 0, 1
+	doc.Nodes = []*gltf.Node{{Mesh: &a}, {Mesh: &b}}
+
+	// Um sampler só, usado pelos dois nós
+	in := modeler.WriteAccessor(doc, gltf.TargetNone, []float32{0, 1})
+	out := modeler.WriteAccessor(doc, gltf.TargetNone, []float32{0, 0, 0, 1, 1, 1})
+	doc.Animations = []*gltf.Animation{{
+		Samplers: []*gltf.AnimationSampler{{Input: in, Output: out}},
+		Channels: []*gltf.AnimationChannel{
+			{Sampler: 0, Target: gltf.AnimationChannelTarget{Node: &a, Path: gltf.TRSWeights}},
+			{Sampler: 0, Target: gltf.AnimationChannelTarget{Node: &b, Path: gltf.TRSWeights}},
+		},
+	}}
+
+	anims, err := decodeAnimations(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anim := anims[0]
+	for i, want := range []int{2, 3} {
+		ch := anim.Channels[i]
+		if got := anim.Samplers[ch.Sampler].Components; got != want {
+			t.Errorf("canal %d: %d componentes, want %d", i, got, want)
+		}
+	}
+	if anim.Samplers[0].Components != 1 {
+		t.Errorf("sampler do documento alterado: %d componentes", anim.Samplers[0].Components)
+	}
+}
+
+func TestApplyAnimationReusesScratch(t *testing.T) {
+	m := &GLTFModel{
+		Nodes:  []*Node{{Parent: -1, Mesh: -1, Rotation: [4]float32{0, 0, 0, 1}, Scale: [3]float32{1, 1, 1}}},
+		Meshes: []*GLTFMesh{{Node: 0}},
+	}
+	anim := &Animation{
+		Samplers: []*AnimationSampler{
+			{Input: []float32{0, 1}, Output: []float32{0, 0, 0, 2, 4, 6}, Components: 3},
+			{Input: []float32{0, 1}, Output: []float32{0, 0, 0, 1, 0, 0, 1, 0}, Components: 4},
+			{Input: []float32{0, 1}, Output: make([]float32, 12), Components: 6},
+		},
+		Channels: []AnimationChannel{
+			{Sampler: 0, Node: 0, Path: PathTranslation},
+			{Sampler: 1, Node: 0, Path: PathRotation},
+			// Mais componentes que um quaternion: o buffer cresce uma vez só
+			{Sampler: 2, Node: 0, Path: PathWeights},
+		},
+	}
+	m.ApplyAnimation(anim, 0)
+	if allocs := testing.AllocsPerRun(100, func() { m.ApplyAnimation(anim, 0.5) }); allocs != 0 {
+		t.Errorf("%v alocações por frame, want 0", allocs)
+	}
+	if got := m.Nodes[0].Translation; !nearFloats(got[:], []float32{1, 2, 3}) {
+		t.Errorf("translation = %v, want [1 2 3]", got)
+	}
+}
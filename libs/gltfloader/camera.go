@@ -0,0 +1,67 @@
+package gltfloader
+
+import "github.com/qmuntal/gltf"
+
+// CameraType é o tipo de projeção de uma câmera glTF.
+type CameraType int
+
+const (
+	CameraPerspective CameraType = iota
+	CameraOrthographic
+)
+
+// Camera é uma câmera glTF instanciada por um nó. A câmera olha para -Z do
+// nó, com +Y para cima; World é o transform do nó e acompanha animações.
+type Camera struct {
+	Name string
+	Node int // índice em Nodes
+	Type CameraType
+
+	// Perspectiva: YFov em radianos. AspectRatio 0 = usar o do viewport.
+	YFov        float32
+	AspectRatio float32
+	// Ortográfica: metade da largura/altura do volume de visão.
+	XMag float32
+	YMag float32
+
+	ZNear float32
+	ZFar  float32 // 0 = projeção perspectiva infinita
+
+	World [16]float32
+}
+
+// decodeCamera converte a câmera cam, instanciada pelo nó nodeIdx.
+func decodeCamera(cam *gltf.Camera, nodeIdx int, world [16]float32) *Camera {
+	c := &Camera{Name: cam.Name, Node: nodeIdx, World: world}
+
+	switch {
+	case cam.Orthographic != nil:
+		o := cam.Orthographic
+		c.Type = CameraOrthographic
+		c.XMag = float32(o.Xmag)
+		c.YMag = float32(o.Ymag)
+		c.ZNear = float32(o.Znear)
+		c.ZFar = float32(o.Zfar)
+	case cam.Perspective != nil:
+		p := cam.Perspective
+		c.Type = CameraPerspective
+		c.YFov = float32(p.Yfov)
+		c.ZNear = float32(p.Znear)
+		if p.AspectRatio != nil {
+			c.AspectRatio = float32(*p.AspectRatio)
+		}
+		if p.Zfar != nil {
+			c.ZFar = float32(*p.Zfar)
+		}
+	}
+	return c
+}
+
+func cloneCameras(cams []*Camera) []*Camera {
+	out := make([]*Camera, len(cams))
+	for i, c := range cams {
+		cp := *c
+		out[i] = &cp
+	}
+	return out
+}
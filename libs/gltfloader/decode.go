@@ -0,0 +1,555 @@
+package gltfloader
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/joaqu1m/gogl-playground/libs/logger"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// MeshData contém os dados de CPU de uma primitiva glTF, sem nenhum recurso
+// OpenGL associado. É o produto do estágio de decode e a entrada do Upload.
+type MeshData struct {
+	Name      string
+	Positions [][3]float32
+	Normals   [][3]float32
+	Tangents  [][4]float32 // xyz + handedness em w; nil sem TANGENT nem normal map
+	UVs       [][2]float32 // nil quando a primitiva não tem TEXCOORD_0
+	UVs1      [][2]float32 // TEXCOORD_1, nil quando ausente
+	Colors    [][4]float32 // COLOR_0 linear RGBA, nil quando ausente
+	Indices   []uint32     // nil quando a primitiva não é indexada
+	Mode      PrimitiveMode
+	// Formats guarda o formato original dos atributos quantizados
+	// (KHR_mesh_quantization), usado no upload. Atributos gerados são float.
+	Formats AttributeFormats
+	Joints  [][4]uint16  // JOINTS_0, nil quando a primitiva não é skinned
+	Weights [][4]float32 // WEIGHTS_0, normalizados para somar 1
+	// Targets são os morph targets da primitiva e MorphWeights os pesos
+	// iniciais (node.weights ou mesh.weights).
+	Targets      []MorphTarget
+	MorphWeights []float32
+	Material     *Material
+	Transform    [16]float32 // Node world transform, column-major
+	Node         int         // índice do nó de origem em ModelData.Nodes, ou -1
+	Skin         int         // índice em ModelData.Skins, ou -1
+	// Instances são as matrizes de EXT_mesh_gpu_instancing, relativas ao
+	// nó (Transform é aplicado por cima); nil quando o nó não é instanciado.
+	Instances [][16]float32
+	// VariantMaterials é o material de cada variante de
+	// KHR_materials_variants (índice em ModelData.Variants) mapeada pela
+	// primitiva; nil sem a extensão.
+	VariantMaterials map[int]*Material
+}
+
+// ModelData agrupa os dados decodificados de um arquivo glTF/GLB.
+// Pode ser inspecionado e testado sem contexto OpenGL.
+type ModelData struct {
+	Meshes     []*MeshData
+	Nodes      []*Node
+	Skins      []*Skin
+	Animations []*Animation
+	Materials  []*Material // mesma ordem de doc.Materials
+	// Cameras e Lights são as instâncias encontradas na cena ativa, na ordem
+	// em que os nós são visitados.
+	Cameras []*Camera
+	Lights  []*Light
+	// Images mapeia o índice da imagem glTF para a imagem já decodificada, e
+	// Textures o índice da textura glTF para o par imagem + sampler.
+	Images   map[int]*image.RGBA
+	Textures map[int]*Texture
+	// Report lista os problemas não fatais encontrados no documento.
+	Report *Report
+	// Variants são os nomes das variantes de material
+	// (KHR_materials_variants), nil sem a extensão.
+	Variants []string
+}
+
+// DecodeFile abre um arquivo .glb/.gltf do disco e decodifica seu conteúdo
+// para CPU. Buffers e imagens externos são resolvidos relativos ao arquivo.
+// As posições são carregadas cruas, sem normalização. Os transforms dos nós
+// da scene graph são armazenados em cada MeshData.Transform.
+func DecodeFile(name string, opts Options) (*ModelData, error) {
+	// A raiz do fsys é a do volume, para que URIs com "../" resolvam
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return nil, fmt.Errorf("gltfloader: falha ao abrir %q: %w", name, err)
+	}
+	root := filepath.VolumeName(abs) + string(filepath.Separator)
+	return decodeFS(os.DirFS(root), filepath.ToSlash(abs[len(root):]), name, opts)
+}
+
+// DecodeFS é como DecodeFile, mas lê o modelo e seus recursos externos de
+// fsys. name usa o formato de caminho de io/fs (separador "/").
+func DecodeFS(fsys fs.FS, name string, opts Options) (*ModelData, error) {
+	return decodeFS(fsys, name, name, opts)
+}
+
+// decodeFS implementa DecodeFile/DecodeFS; label é o nome usado nos erros.
+func decodeFS(fsys fs.FS, name, label string, opts Options) (*ModelData, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("gltfloader: falha ao abrir %q: %w", label, err)
+	}
+	defer f.Close()
+
+	// Recursos externos são relativos ao diretório do arquivo
+	dir := uriFS{fsys: fsys, dir: path.Dir(name)}
+
+	raw, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("gltfloader: falha ao abrir %q: %w", label, err)
+	}
+
+	raw, external := detachExternalBuffers(patchMeshoptFallbacks(raw))
+	doc := new(gltf.Document)
+	if err := gltf.NewDecoderFS(bytes.NewReader(raw), dir).Decode(doc); err != nil {
+		return nil, fmt.Errorf("gltfloader: falha ao abrir %q: %w", label, err)
+	}
+	if err := loadExternalBuffers(doc, external, dir); err != nil {
+		return nil, fmt.Errorf("gltfloader: falha ao abrir %q: %w", label, err)
+	}
+
+	data, err := Decode(doc, dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data.Meshes) == 0 {
+		return nil, fmt.Errorf("gltfloader: nenhuma mesh encontrada em %q", label)
+	}
+
+	return data, nil
+}
+
+// Decode converte um documento glTF já aberto em ModelData. Imagens
+// referenciadas por URI são lidas de fsys, relativo à raiz dele; fsys pode
+// ser nil se o documento não tiver imagens externas. opts controla o
+// processamento da geometria (ver Options).
+//
+// O documento é validado antes (ver Validate); se houver erros, Decode
+// retorna um *ValidationError com o Report completo. Os problemas restantes
+// ficam em ModelData.Report e são logados. Buffer views comprimidos
+// (EXT_meshopt_compression) e accessors esparsos são resolvidos no próprio
+// doc antes da validação.
+func Decode(doc *gltf.Document, fsys fs.FS, opts Options) (*ModelData, error) {
+	if err := decompressMeshopt(doc); err != nil {
+		return nil, fmt.Errorf("gltfloader: falha ao descomprimir %s: %w", meshoptExtension, err)
+	}
+	if err := resolveSparse(doc); err != nil {
+		return nil, fmt.Errorf("gltfloader: falha ao resolver accessors esparsos: %w", err)
+	}
+
+	report := Validate(doc)
+	defer report.Log()
+	if report.HasErrors() {
+		return nil, &ValidationError{Report: report}
+	}
+
+	images, textures, err := decodeTextures(doc, fsys, report)
+	if err != nil {
+		return nil, fmt.Errorf("gltfloader: falha ao carregar texturas: %w", err)
+	}
+
+	skins, err := decodeSkins(doc)
+	if err != nil {
+		return nil, fmt.Errorf("gltfloader: falha ao carregar skins: %w", err)
+	}
+
+	animations, err := decodeAnimations(doc)
+	if err != nil {
+		return nil, fmt.Errorf("gltfloader: falha ao carregar animações: %w", err)
+	}
+
+	data := &ModelData{
+		Images:     images,
+		Textures:   textures,
+		Nodes:      decodeNodes(doc),
+		Skins:      skins,
+		Animations: animations,
+		Materials:  decodeMaterials(doc),
+		Report:     report,
+		Variants:   decodeVariants(doc),
+	}
+	for _, skin := range data.Skins {
+		skin.updatePalette(data.Nodes)
+	}
+
+	if len(doc.Scenes) > 0 {
+		// Percorre a scene graph a partir da cena ativa
+		sceneIdx := 0
+		if doc.Scene != nil {
+			sceneIdx = *doc.Scene
+		}
+		scene := doc.Scenes[sceneIdx]
+		for _, nodeIdx := range scene.Nodes {
+			if err := processNode(doc, nodeIdx, mat4fIdentity(), data, opts); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// Fallback: sem cenas definidas, carrega todas as meshes com transform identidade
+		for meshIdx, mesh := range doc.Meshes {
+			for primIdx, prim := range mesh.Primitives {
+				meshData, err := decodePrimitive(doc, prim, primitivePath(meshIdx, primIdx), data.Materials, opts, report)
+				if err != nil {
+					return nil, fmt.Errorf("gltfloader: falha ao carregar primitiva de %q: %w", mesh.Name, err)
+				}
+				meshData.Name = mesh.Name
+				meshData.Transform = mat4fIdentity()
+				meshData.Node = -1
+				meshData.MorphWeights = defaultMorphWeights(nil, mesh, len(meshData.Targets))
+				data.Meshes = append(data.Meshes, meshData)
+			}
+		}
+	}
+
+	return data, nil
+}
+
+// processNode percorre recursivamente a árvore de nós, acumulando transforms.
+func processNode(doc *gltf.Document, nodeIdx int, parentTransform [16]float32, data *ModelData, opts Options) error {
+	if nodeIdx < 0 || nodeIdx >= len(doc.Nodes) {
+		return fmt.Errorf("gltfloader: node index %d fora do range", nodeIdx)
+	}
+	node := doc.Nodes[nodeIdx]
+
+	localTransform := nodeLocalTransform(node)
+	worldTransform := mat4fMul(parentTransform, localTransform)
+
+	if node.Camera != nil {
+		if *node.Camera < 0 || *node.Camera >= len(doc.Cameras) {
+			return fmt.Errorf("gltfloader: camera index %d fora do range", *node.Camera)
+		}
+		data.Cameras = append(data.Cameras, decodeCamera(doc.Cameras[*node.Camera], nodeIdx, worldTransform))
+	}
+
+	light, err := nodeLight(doc, node)
+	if err != nil {
+		return fmt.Errorf("gltfloader: nó %d: %w", nodeIdx, err)
+	}
+	if light != nil {
+		data.Lights = append(data.Lights, decodeLight(light, nodeIdx, worldTransform))
+	}
+
+	if node.Mesh != nil {
+		meshIdx := *node.Mesh
+		if meshIdx < 0 || meshIdx >= len(doc.Meshes) {
+			return fmt.Errorf("gltfloader: mesh index %d fora do range", meshIdx)
+		}
+		mesh := doc.Meshes[meshIdx]
+		instances, err := decodeInstances(doc, node)
+		if err != nil {
+			return fmt.Errorf("gltfloader: nó %d: %s: %w", nodeIdx, instancingExtension, err)
+		}
+		for primIdx, prim := range mesh.Primitives {
+			meshData, err := decodePrimitive(doc, prim, primitivePath(meshIdx, primIdx), data.Materials, opts, data.Report)
+			if err != nil {
+				return fmt.Errorf("gltfloader: falha ao carregar primitiva de %q: %w", mesh.Name, err)
+			}
+			meshData.Name = mesh.Name
+			meshData.Transform = worldTransform
+			meshData.Node = nodeIdx
+			meshData.MorphWeights = defaultMorphWeights(node, mesh, len(meshData.Targets))
+			meshData.Instances = instances
+			if node.Skin != nil && len(meshData.Joints) > 0 {
+				if *node.Skin < 0 || *node.Skin >= len(data.Skins) {
+					return fmt.Errorf("gltfloader: skin index %d fora do range", *node.Skin)
+				}
+				meshData.Skin = *node.Skin
+			}
+			data.Nodes[nodeIdx].Meshes = append(data.Nodes[nodeIdx].Meshes, len(data.Meshes))
+			data.Meshes = append(data.Meshes, meshData)
+
+			logger.Infof("mesh %q: node=%q transform=[%.3f, %.3f, %.3f, %.3f | %.3f, %.3f, %.3f, %.3f | %.3f, %.3f, %.3f, %.3f | %.3f, %.3f, %.3f, %.3f]",
+				mesh.Name, node.Name,
+				worldTransform[0], worldTransform[1], worldTransform[2], worldTransform[3],
+				worldTransform[4], worldTransform[5], worldTransform[6], worldTransform[7],
+				worldTransform[8], worldTransform[9], worldTransform[10], worldTransform[11],
+				worldTransform[12], worldTransform[13], worldTransform[14], worldTransform[15],
+			)
+		}
+	}
+
+	for _, childIdx := range node.Children {
+		if err := processNode(doc, childIdx, worldTransform, data, opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nodeLocalTransform calcula a matriz de transformação local de um nó.
+func nodeLocalTransform(node *gltf.Node) [16]float32 {
+	// A biblioteca qmuntal/gltf inicializa Matrix com DefaultMatrix (identidade)
+	// no UnmarshalJSON, mesmo que o JSON não contenha "matrix". Então comparamos
+	// contra DefaultMatrix: se for diferente, o nó definiu uma matriz explícita.
+	if node.Matrix != gltf.DefaultMatrix {
+		var m [16]float32
+		for i, v := range node.Matrix {
+			m[i] = float32(v)
+		}
+		return m
+	}
+
+	// Caso contrário, compor T * R * S
+	t := node.TranslationOrDefault()
+	r := node.RotationOrDefault()
+	s := node.ScaleOrDefault()
+	return composeTRS(
+		[3]float32{float32(t[0]), float32(t[1]), float32(t[2])},
+		[4]float32{float32(r[0]), float32(r[1]), float32(r[2]), float32(r[3])},
+		[3]float32{float32(s[0]), float32(s[1]), float32(s[2])},
+	)
+}
+
+// primitivePath é o JSON pointer de uma primitiva, usado no Report.
+func primitivePath(meshIdx, primIdx int) string {
+	return fmt.Sprintf("/meshes/%d/primitives/%d", meshIdx, primIdx)
+}
+
+// decodePrimitive lê os atributos de uma primitiva glTF para a memória.
+// As posições são carregadas cruas, sem normalização. Atributos descartados,
+// gerados ou com dados suspeitos são registrados em r, sob path.
+func decodePrimitive(doc *gltf.Document, prim *gltf.Primitive, path string, materials []*Material, opts Options, r *Report) (*MeshData, error) {
+	// ---- Lê posições (obrigatório) ----
+	posAccessorIdx, ok := prim.Attributes[gltf.POSITION]
+	if !ok {
+		return nil, fmt.Errorf("primitiva sem POSITION")
+	}
+	posAccessor := doc.Accessors[posAccessorIdx]
+	posData, err := readVec[[3]float32](doc, posAccessor)
+	if err != nil {
+		return nil, fmt.Errorf("erro lendo posições: %w", err)
+	}
+	formats := AttributeFormats{Position: accessorFormat(posAccessor)}
+
+	// Log vertex bounds para debug
+	if len(posData) > 0 {
+		minP := posData[0]
+		maxP := posData[0]
+		for _, p := range posData[1:] {
+			for axis := 0; axis < 3; axis++ {
+				if p[axis] < minP[axis] {
+					minP[axis] = p[axis]
+				}
+				if p[axis] > maxP[axis] {
+					maxP[axis] = p[axis]
+				}
+			}
+		}
+		logger.Infof("  primitive %d verts: bounds min=(%.4f, %.4f, %.4f) max=(%.4f, %.4f, %.4f)",
+			len(posData),
+			minP[0], minP[1], minP[2],
+			maxP[0], maxP[1], maxP[2],
+		)
+	}
+
+	// ---- Lê normais (opcional) ----
+	var normalData [][3]float32
+	if normIdx, ok := prim.Attributes[gltf.NORMAL]; ok {
+		normalData, err = readVec[[3]float32](doc, doc.Accessors[normIdx])
+		if err != nil {
+			r.add(SeverityWarning, path+"/attributes/NORMAL", "normais ignoradas: %v", err)
+			normalData = nil // fallback: calcula depois
+		} else {
+			formats.Normal = accessorFormat(doc.Accessors[normIdx])
+		}
+	}
+
+	// ---- Lê tangentes (opcional) ----
+	var tangentData [][4]float32
+	if tanIdx, ok := prim.Attributes[gltf.TANGENT]; ok {
+		tangentData, err = readVec[[4]float32](doc, doc.Accessors[tanIdx])
+		if err != nil {
+			r.add(SeverityWarning, path+"/attributes/TANGENT", "tangentes ignoradas: %v", err)
+			tangentData = nil // fallback: gera depois
+		} else if len(tangentData) != len(posData) {
+			r.add(SeverityWarning, path+"/attributes/TANGENT", "tangentes ignoradas: %d elementos, esperado %d", len(tangentData), len(posData))
+			tangentData = nil
+		} else {
+			formats.Tangent = accessorFormat(doc.Accessors[tanIdx])
+		}
+	}
+
+	// ---- Lê UVs (opcional) ----
+	var uvData [][2]float32
+	if uvIdx, ok := prim.Attributes[gltf.TEXCOORD_0]; ok {
+		uvData, err = readVec[[2]float32](doc, doc.Accessors[uvIdx])
+		if err != nil {
+			r.add(SeverityWarning, path+"/attributes/TEXCOORD_0", "UVs ignoradas: %v", err)
+			uvData = nil
+		} else {
+			formats.UV = accessorFormat(doc.Accessors[uvIdx])
+		}
+	}
+	var uv1Data [][2]float32
+	if uvIdx, ok := prim.Attributes[gltf.TEXCOORD_1]; ok {
+		uv1Data, err = readVec[[2]float32](doc, doc.Accessors[uvIdx])
+		if err != nil {
+			r.add(SeverityWarning, path+"/attributes/TEXCOORD_1", "UVs ignoradas: %v", err)
+			uv1Data = nil
+		} else {
+			formats.UV1 = accessorFormat(doc.Accessors[uvIdx])
+		}
+	}
+
+	// ---- Lê cor de vértice (opcional) ----
+	var colorData [][4]float32
+	if colIdx, ok := prim.Attributes[gltf.COLOR_0]; ok {
+		colorData, err = readColors(doc, doc.Accessors[colIdx])
+		if err != nil {
+			r.add(SeverityWarning, path+"/attributes/COLOR_0", "cores de vértice ignoradas: %v", err)
+			colorData = nil
+		}
+	}
+
+	// ---- Lê joints/weights de skinning (opcional) ----
+	var jointData [][4]uint16
+	var weightData [][4]float32
+	if jIdx, ok := prim.Attributes[gltf.JOINTS_0]; ok {
+		wIdx, ok := prim.Attributes[gltf.WEIGHTS_0]
+		if !ok {
+			return nil, fmt.Errorf("primitiva com JOINTS_0 sem WEIGHTS_0")
+		}
+		jointData, err = modeler.ReadJoints(doc, doc.Accessors[jIdx], nil)
+		if err != nil {
+			return nil, fmt.Errorf("erro lendo joints: %w", err)
+		}
+		weightData, err = modeler.ReadWeights(doc, doc.Accessors[wIdx], nil)
+		if err != nil {
+			return nil, fmt.Errorf("erro lendo weights: %w", err)
+		}
+		normalizeWeights(weightData)
+	}
+
+	// ---- Lê morph targets (opcional) ----
+	targets, err := decodeMorphTargets(doc, prim, len(posData))
+	if err != nil {
+		return nil, fmt.Errorf("erro lendo morph targets: %w", err)
+	}
+
+	// ---- Lê índices (opcional) ----
+	var indices []uint32
+	if prim.Indices != nil {
+		indData, err := modeler.ReadIndices(doc, doc.Accessors[*prim.Indices], nil)
+		if err != nil {
+			return nil, fmt.Errorf("erro lendo índices: %w", err)
+		}
+		indices = indData
+	}
+	if err := checkTriangles(convertMode(prim.Mode), indices, posData, path, r); err != nil {
+		return nil, err
+	}
+
+	// ---- Material ----
+	material := DefaultMaterial()
+	if prim.Material != nil {
+		if *prim.Material < 0 || *prim.Material >= len(materials) {
+			return nil, fmt.Errorf("material index %d fora do range", *prim.Material)
+		}
+		material = materials[*prim.Material]
+	}
+
+	md := &MeshData{
+		Positions: posData,
+		Normals:   normalData,
+		Tangents:  tangentData,
+		UVs:       uvData,
+		UVs1:      uv1Data,
+		Colors:    colorData,
+		Indices:   indices,
+		Mode:      convertMode(prim.Mode),
+		Joints:    jointData,
+		Weights:   weightData,
+		Targets:   targets,
+		Formats:   formats,
+		Material:  material,
+		Node:      -1,
+		Skin:      -1,
+
+		VariantMaterials: decodeVariantMappings(prim, materials),
+	}
+
+	// ---- Gera normais se não existirem ----
+	// Pontos e linhas não têm faces; ficam com a normal padrão do upload.
+	if md.Normals == nil && md.Mode.IsTriangles() {
+		r.add(SeverityInfo, path+"/attributes", "normais geradas")
+		generateNormals(md, opts)
+	}
+
+	// ---- Gera tangentes se não existirem ----
+	// Só servem ao normal map, então só são geradas quando o material usa
+	// um, com as UVs do texCoord dele.
+	if md.Tangents == nil && md.Mode.IsTriangles() {
+		if uvs := normalMapUVs(md); uvs != nil {
+			generateMikkTSpaceTangents(md, uvs)
+		}
+	}
+
+	return md, nil
+}
+
+// normalMapUVs retorna o conjunto de UVs do normal map da primitiva (o do
+// material padrão ou, sem ele, o da primeira variante que tiver um), ou nil
+// se nenhum material usa normal map ou as UVs não existem.
+func normalMapUVs(md *MeshData) [][2]float32 {
+	materials := []*Material{md.Material}
+	variants := make([]int, 0, len(md.VariantMaterials))
+	for v := range md.VariantMaterials {
+		variants = append(variants, v)
+	}
+	sort.Ints(variants)
+	for _, v := range variants {
+		materials = append(materials, md.VariantMaterials[v])
+	}
+
+	for _, m := range materials {
+		ref := m.NormalTexture
+		if ref == nil && m.Clearcoat != nil {
+			ref = m.Clearcoat.NormalTexture
+		}
+		if ref == nil {
+			continue
+		}
+		switch ref.TexCoord {
+		case 0:
+			return md.UVs
+		case 1:
+			return md.UVs1
+		}
+		return nil
+	}
+	return nil
+}
+
+// readColors lê um accessor COLOR_n (vec3 ou vec4; float ou inteiro
+// normalizado) como RGBA. Cores vec3 ganham alpha 1.
+func readColors(doc *gltf.Document, acr *gltf.Accessor) ([][4]float32, error) {
+	flat, components, err := readFloats(doc, acr)
+	if err != nil {
+		return nil, err
+	}
+	if components != 3 && components != 4 {
+		return nil, fmt.Errorf("cor com %d componentes, esperado 3 ou 4", components)
+	}
+
+	colors := make([][4]float32, len(flat)/components)
+	for i := range colors {
+		c := flat[i*components : (i+1)*components]
+		colors[i] = [4]float32{c[0], c[1], c[2], 1}
+		if components == 4 {
+			colors[i][3] = c[3]
+		}
+	}
+	return colors, nil
+}
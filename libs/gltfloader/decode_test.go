@@ -0,0 +1,163 @@
+package gltfloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"testing"
+	"testing/fstest"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+func TestDecodeMeshAndNodes(t *testing.T) {
+	doc := gltf.NewDocument()
+	positions := [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0}}
+	normals := [][3]float32{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}}
+	indices := []uint16{0, 1, 2, 2, 1, 3}
+
+	mat := 0
+	doc.Materials = []*gltf.Material{{Name: "verde"}}
+	doc.Meshes = []*gltf.Mesh{{
+		Name: "quad",
+		Primitives: []*gltf.Primitive{{
+			Attributes: gltf.PrimitiveAttributes{
+				gltf.POSITION: modeler.WritePosition(doc, positions),
+				gltf.NORMAL:   modeler.WriteNormal(doc, normals),
+			},
+			Indices:  gltf.Index(modeler.WriteIndices(doc, indices)),
+			Material: &mat,
+		}},
+	}}
+
+	// Raiz transladada com um filho que carrega a mesh
+	mesh := 0
+	doc.Nodes = []*gltf.Node{
+		{Name: "raiz", Children: []int{1}, Translation: [3]float64{1, 2, 3}, Rotation: gltf.DefaultRotation, Scale: gltf.DefaultScale, Matrix: gltf.DefaultMatrix},
+		{Name: "filho", Mesh: &mesh, Translation: [3]float64{0, 0, 5}, Rotation: gltf.DefaultRotation, Scale: gltf.DefaultScale, Matrix: gltf.DefaultMatrix},
+	}
+	doc.Scenes[0].Nodes = []int{0}
+
+	data, err := Decode(doc, nil, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if data.Report.HasErrors() {
+		t.Fatalf("report com erros: %v", data.Report.Issues)
+	}
+
+	if len(data.Meshes) != 1 {
+		t.Fatalf("esperado 1 mesh, got %d", len(data.Meshes))
+	}
+	m := data.Meshes[0]
+	if !equalVec3(m.Positions, positions) {
+		t.Errorf("posições: got %v, want %v", m.Positions, positions)
+	}
+	if !equalVec3(m.Normals, normals) {
+		t.Errorf("normais: got %v, want %v", m.Normals, normals)
+	}
+	wantIdx := []uint32{0, 1, 2, 2, 1, 3}
+	if len(m.Indices) != len(wantIdx) {
+		t.Fatalf("índices: got %v, want %v", m.Indices, wantIdx)
+	}
+	for i := range wantIdx {
+		if m.Indices[i] != wantIdx[i] {
+			t.Fatalf("índices: got %v, want %v", m.Indices, wantIdx)
+		}
+	}
+	if m.UVs != nil {
+		t.Errorf("UVs sem TEXCOORD_0: got %v, want nil", m.UVs)
+	}
+	if m.Material == nil || m.Material.Name != "verde" || m.Material != data.Materials[0] {
+		t.Errorf("material da mesh não é o do documento: %+v", m.Material)
+	}
+	if m.Node != 1 || m.Skin != -1 {
+		t.Errorf("Node/Skin: got %d/%d, want 1/-1", m.Node, m.Skin)
+	}
+	// Column-major: translação nas posições 12..14
+	if got := [3]float32{m.Transform[12], m.Transform[13], m.Transform[14]}; got != [3]float32{1, 2, 8} {
+		t.Errorf("translação de mundo da mesh: got %v, want [1 2 8]", got)
+	}
+
+	if len(data.Nodes) != 2 {
+		t.Fatalf("esperado 2 nós, got %d", len(data.Nodes))
+	}
+	root, child := data.Nodes[0], data.Nodes[1]
+	if root.Parent != -1 || child.Parent != 0 {
+		t.Errorf("parents: got %d/%d, want -1/0", root.Parent, child.Parent)
+	}
+	if len(child.Meshes) != 1 || child.Meshes[0] != 0 || len(root.Meshes) != 0 {
+		t.Errorf("meshes dos nós: raiz %v, filho %v", root.Meshes, child.Meshes)
+	}
+	if child.Translation != [3]float32{0, 0, 5} {
+		t.Errorf("translação local do filho: got %v", child.Translation)
+	}
+	if child.World != m.Transform {
+		t.Errorf("World do filho difere do Transform da mesh: %v != %v", child.World, m.Transform)
+	}
+}
+
+// relativeURIFS monta um .gltf em models/ cujo buffer e imagem ficam em
+// pastas irmãs, referenciados por bufferURI e imageURI.
+func relativeURIFS(t *testing.T, bufferURI, imageURI string) fstest.MapFS {
+	t.Helper()
+	doc := validDoc()
+	bin := doc.Buffers[0].Data
+	doc.Buffers[0].URI = bufferURI
+	doc.Images = []*gltf.Image{{URI: imageURI}}
+	doc.Textures = []*gltf.Texture{{Source: gltf.Index(0)}}
+
+	js, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	return fstest.MapFS{
+		"assets/models/tri.gltf":  {Data: js},
+		"assets/buffers/tri.bin":  {Data: bin},
+		"assets/textures/tri.png": {Data: img.Bytes()},
+	}
+}
+
+func TestDecodeFSRelativeURIs(t *testing.T) {
+	tests := []struct {
+		name      string
+		bufferURI string
+		imageURI  string
+		wantErr   bool
+	}{
+		{"../ resolvido contra o .gltf", "../buffers/tri.bin", "../textures/tri.png", false},
+		{"./ e ../ no meio", "./../buffers/../buffers/tri.bin", "../models/../textures/tri.png", false},
+		{"escapes", "..%2Fbuffers%2Ftri.bin", "../textures/tri.png", false},
+		{"buffer absoluto", "/assets/buffers/tri.bin", "../textures/tri.png", true},
+		{"buffer fora do fsys", "../../../buffers/tri.bin", "../textures/tri.png", true},
+		{"imagem absoluta", "../buffers/tri.bin", "/assets/textures/tri.png", true},
+		{"imagem com esquema", "../buffers/tri.bin", "file:///assets/textures/tri.png", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := relativeURIFS(t, tt.bufferURI, tt.imageURI)
+			data, err := DecodeFS(fsys, "assets/models/tri.gltf", DefaultOptions())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("esperado erro")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeFS: %v", err)
+			}
+			if len(data.Meshes) != 1 || len(data.Meshes[0].Positions) != 3 {
+				t.Errorf("mesh não decodificada: %+v", data.Meshes)
+			}
+			if data.Images[0] == nil {
+				t.Error("imagem não carregada")
+			}
+		})
+	}
+}
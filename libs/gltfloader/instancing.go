@@ -0,0 +1,108 @@
+package gltfloader
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/qmuntal/gltf"
+)
+
+// instancingExtension é o EXT_mesh_gpu_instancing: o nó desenha várias
+// cópias da mesh, cada uma com seu TRS relativo ao nó.
+const instancingExtension = "EXT_mesh_gpu_instancing"
+
+// instanceAttribLoc é a primeira location da matriz de instância no vertex
+// shader; um mat4 ocupa quatro locations seguidas (8 a 11).
+const instanceAttribLoc = 8
+
+// decodeInstances lê as instâncias de EXT_mesh_gpu_instancing de um nó como
+// matrizes column-major, relativas ao nó. Retorna nil sem a extensão.
+// Atributos ausentes usam o TRS padrão.
+func decodeInstances(doc *gltf.Document, node *gltf.Node) ([][16]float32, error) {
+	var ext struct {
+		Attributes map[string]int `json:"attributes"`
+	}
+	if !decodeExtension(node.Extensions, instancingExtension, &ext) {
+		return nil, nil
+	}
+
+	count := -1
+	accessor := func(name string) (*gltf.Accessor, error) {
+		idx, ok := ext.Attributes[name]
+		if !ok {
+			return nil, nil
+		}
+		if idx < 0 || idx >= len(doc.Accessors) {
+			return nil, fmt.Errorf("accessor %d de %s fora do range", idx, name)
+		}
+		acr := doc.Accessors[idx]
+		if count >= 0 && acr.Count != count {
+			return nil, fmt.Errorf("%s tem %d instâncias, esperado %d", name, acr.Count, count)
+		}
+		count = acr.Count
+		return acr, nil
+	}
+
+	var translations, scales [][3]float32
+	var rotations [][4]float32
+	if acr, err := accessor("TRANSLATION"); err != nil {
+		return nil, err
+	} else if acr != nil {
+		if translations, err = readVec[[3]float32](doc, acr); err != nil {
+			return nil, fmt.Errorf("falha ao ler TRANSLATION: %w", err)
+		}
+	}
+	if acr, err := accessor("ROTATION"); err != nil {
+		return nil, err
+	} else if acr != nil {
+		if rotations, err = readVec[[4]float32](doc, acr); err != nil {
+			return nil, fmt.Errorf("falha ao ler ROTATION: %w", err)
+		}
+	}
+	if acr, err := accessor("SCALE"); err != nil {
+		return nil, err
+	} else if acr != nil {
+		if scales, err = readVec[[3]float32](doc, acr); err != nil {
+			return nil, fmt.Errorf("falha ao ler SCALE: %w", err)
+		}
+	}
+	if count <= 0 {
+		return nil, nil
+	}
+
+	instances := make([][16]float32, count)
+	for i := range instances {
+		t := [3]float32{0, 0, 0}
+		r := [4]float32{0, 0, 0, 1}
+		s := [3]float32{1, 1, 1}
+		if translations != nil {
+			t = translations[i]
+		}
+		if rotations != nil {
+			r = rotations[i]
+		}
+		if scales != nil {
+			s = scales[i]
+		}
+		instances[i] = composeTRS(t, r, s)
+	}
+	return instances, nil
+}
+
+// uploadInstances cria o buffer de matrizes de instância e o liga ao VAO
+// atual nas locations instanceAttribLoc..+3, avançando uma vez por
+// instância.
+func uploadInstances(instances [][16]float32) uint32 {
+	var vbo uint32
+	gl.GenBuffers(1, &vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(instances)*16*4, gl.Ptr(&instances[0][0]), gl.STATIC_DRAW)
+
+	for col := 0; col < 4; col++ {
+		loc := uint32(instanceAttribLoc + col)
+		gl.VertexAttribPointer(loc, 4, gl.FLOAT, false, 16*4, gl.PtrOffset(col*4*4))
+		gl.EnableVertexAttribArray(loc)
+		gl.VertexAttribDivisor(loc, 1)
+	}
+	return vbo
+}
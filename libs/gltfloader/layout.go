@@ -0,0 +1,192 @@
+package gltfloader
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/qmuntal/gltf"
+)
+
+// quantizationExtension é o KHR_mesh_quantization. Não tem dados próprios:
+// só libera tipos inteiros nos atributos de vértice.
+const quantizationExtension = "KHR_mesh_quantization"
+
+// AttributeFormat é o tipo de componente com que um atributo de vértice é
+// guardado no vertex buffer. Atributos quantizados (KHR_mesh_quantization)
+// mantêm o formato do arquivo e são convertidos para float pelo próprio
+// OpenGL, com ou sem normalização.
+type AttributeFormat int
+
+const (
+	FormatFloat AttributeFormat = iota
+	FormatByte
+	FormatUbyte
+	FormatShort
+	FormatUshort
+	FormatByteNorm
+	FormatUbyteNorm
+	FormatShortNorm
+	FormatUshortNorm
+)
+
+// AttributeFormats são os formatos dos atributos que podem ser quantizados.
+// O zero value é tudo float.
+type AttributeFormats struct {
+	Position AttributeFormat
+	Normal   AttributeFormat
+	Tangent  AttributeFormat
+	UV       AttributeFormat
+	UV1      AttributeFormat
+}
+
+// accessorFormat retorna o formato de um accessor de atributo. Tipos que não
+// existem em atributos de vértice caem em FormatFloat.
+func accessorFormat(acr *gltf.Accessor) AttributeFormat {
+	switch acr.ComponentType {
+	case gltf.ComponentByte:
+		if acr.Normalized {
+			return FormatByteNorm
+		}
+		return FormatByte
+	case gltf.ComponentUbyte:
+		if acr.Normalized {
+			return FormatUbyteNorm
+		}
+		return FormatUbyte
+	case gltf.ComponentShort:
+		if acr.Normalized {
+			return FormatShortNorm
+		}
+		return FormatShort
+	case gltf.ComponentUshort:
+		if acr.Normalized {
+			return FormatUshortNorm
+		}
+		return FormatUshort
+	}
+	return FormatFloat
+}
+
+// size é o tamanho de um componente, em bytes.
+func (f AttributeFormat) size() int {
+	switch f {
+	case FormatByte, FormatUbyte, FormatByteNorm, FormatUbyteNorm:
+		return 1
+	case FormatShort, FormatUshort, FormatShortNorm, FormatUshortNorm:
+		return 2
+	}
+	return 4
+}
+
+func (f AttributeFormat) glType() uint32 {
+	switch f {
+	case FormatByte, FormatByteNorm:
+		return gl.BYTE
+	case FormatUbyte, FormatUbyteNorm:
+		return gl.UNSIGNED_BYTE
+	case FormatShort, FormatShortNorm:
+		return gl.SHORT
+	case FormatUshort, FormatUshortNorm:
+		return gl.UNSIGNED_SHORT
+	}
+	return gl.FLOAT
+}
+
+func (f AttributeFormat) normalized() bool {
+	return f >= FormatByteNorm
+}
+
+// put codifica v em dst no formato f. É o inverso da leitura: valores
+// normalizados voltam para o inteiro original.
+func (f AttributeFormat) put(dst []byte, v float32) {
+	switch f {
+	case FormatByte:
+		dst[0] = byte(int8(quantize(v, math.MinInt8, math.MaxInt8, 1)))
+	case FormatUbyte:
+		dst[0] = uint8(quantize(v, 0, math.MaxUint8, 1))
+	case FormatShort:
+		binary.LittleEndian.PutUint16(dst, uint16(int16(quantize(v, math.MinInt16, math.MaxInt16, 1))))
+	case FormatUshort:
+		binary.LittleEndian.PutUint16(dst, uint16(quantize(v, 0, math.MaxUint16, 1)))
+	case FormatByteNorm:
+		dst[0] = byte(int8(quantize(v, -math.MaxInt8, math.MaxInt8, math.MaxInt8)))
+	case FormatUbyteNorm:
+		dst[0] = uint8(quantize(v, 0, math.MaxUint8, math.MaxUint8))
+	case FormatShortNorm:
+		binary.LittleEndian.PutUint16(dst, uint16(int16(quantize(v, -math.MaxInt16, math.MaxInt16, math.MaxInt16))))
+	case FormatUshortNorm:
+		binary.LittleEndian.PutUint16(dst, uint16(quantize(v, 0, math.MaxUint16, math.MaxUint16)))
+	default:
+		binary.LittleEndian.PutUint32(dst, math.Float32bits(v))
+	}
+}
+
+// quantize arredonda v*scale para o inteiro mais próximo em [lo, hi].
+func quantize(v float32, lo, hi, scale float64) int64 {
+	q := math.Round(float64(v) * scale)
+	return int64(math.Max(lo, math.Min(hi, q)))
+}
+
+// vertexAttrib é um atributo dentro do vertex buffer interleaved.
+type vertexAttrib struct {
+	loc        uint32
+	components int
+	format     AttributeFormat
+	src        int // offset no vértice float (ver floatsPerVertex)
+	offset     int // offset no vértice empacotado, em bytes
+}
+
+// vertexLayout descreve o vertex buffer de uma mesh. Com todos os formatos
+// float ele coincide com o vértice de floatsPerVertex floats.
+type vertexLayout struct {
+	attribs []vertexAttrib
+	stride  int
+}
+
+// newVertexLayout monta o layout das locations do shader com os formatos
+// dados. Cada atributo começa alinhado a 4 bytes.
+func newVertexLayout(f AttributeFormats) vertexLayout {
+	attribs := []vertexAttrib{
+		{loc: 0, components: 3, format: f.Position, src: 0},
+		{loc: 1, components: 3, format: f.Normal, src: 3},
+		{loc: 2, components: 2, format: f.UV, src: 6},
+		{loc: 3, components: 4, format: FormatFloat, src: 8},  // joints
+		{loc: 4, components: 4, format: FormatFloat, src: 12}, // weights
+		{loc: 5, components: 4, format: f.Tangent, src: 16},
+		{loc: 6, components: 4, format: FormatFloat, src: 20}, // cor
+		{loc: 7, components: 2, format: f.UV1, src: 24},
+	}
+
+	offset := 0
+	for i := range attribs {
+		attribs[i].offset = offset
+		offset += (attribs[i].components*attribs[i].format.size() + 3) &^ 3
+	}
+	return vertexLayout{attribs: attribs, stride: offset}
+}
+
+// pack converte o buffer de vértices float para o layout.
+func (l vertexLayout) pack(floats []float32) []byte {
+	vertCount := len(floats) / floatsPerVertex
+	out := make([]byte, vertCount*l.stride)
+	for v := 0; v < vertCount; v++ {
+		src := floats[v*floatsPerVertex:]
+		dst := out[v*l.stride:]
+		for _, a := range l.attribs {
+			size := a.format.size()
+			for c := 0; c < a.components; c++ {
+				a.format.put(dst[a.offset+c*size:], src[a.src+c])
+			}
+		}
+	}
+	return out
+}
+
+// bind configura os vertex attrib pointers do VAO ligado.
+func (l vertexLayout) bind() {
+	for _, a := range l.attribs {
+		gl.VertexAttribPointer(a.loc, int32(a.components), a.format.glType(), a.format.normalized(), int32(l.stride), gl.PtrOffset(a.offset))
+		gl.EnableVertexAttribArray(a.loc)
+	}
+}
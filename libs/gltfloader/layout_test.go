@@ -0,0 +1,119 @@
+package gltfloader
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// quantizedAccessor grava data como um accessor inteiro (o tipo vem do
+// slice) e retorna o accessor com normalized ajustado.
+func quantizedAccessor(doc *gltf.Document, data any, normalized bool) *gltf.Accessor {
+	idx := modeler.WriteAccessor(doc, gltf.TargetArrayBuffer, data)
+	acr := doc.Accessors[idx]
+	acr.Normalized = normalized
+	return acr
+}
+
+func TestReadVecDequantizes(t *testing.T) {
+	doc := gltf.NewDocument()
+	tests := []struct {
+		name   string
+		acr    *gltf.Accessor
+		want   [][3]float32
+		format AttributeFormat
+	}{
+		{
+			name:   "int16 normalizado",
+			acr:    quantizedAccessor(doc, [][3]int16{{32767, 0, -32767}, {-32768, 16384, 0}}, true),
+			want:   [][3]float32{{1, 0, -1}, {-1, 16384.0 / 32767, 0}},
+			format: FormatShortNorm,
+		},
+		{
+			name:   "int8 normalizado",
+			acr:    quantizedAccessor(doc, [][3]int8{{127, -127, -128}, {0, 64, 0}}, true),
+			want:   [][3]float32{{1, -1, -1}, {0, 64.0 / 127, 0}},
+			format: FormatByteNorm,
+		},
+		{
+			name:   "uint8 normalizado",
+			acr:    quantizedAccessor(doc, [][3]uint8{{255, 0, 51}}, true),
+			want:   [][3]float32{{1, 0, 0.2}},
+			format: FormatUbyteNorm,
+		},
+		{
+			// Sem normalized o inteiro vira float direto (posições do gltfpack)
+			name:   "int16 sem normalizar",
+			acr:    quantizedAccessor(doc, [][3]int16{{300, -2, 0}}, false),
+			want:   [][3]float32{{300, -2, 0}},
+			format: FormatShort,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readVec[[3]float32](doc, tt.acr)
+			if err != nil {
+				t.Fatalf("readVec: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !nearFloats(got[i][:], tt.want[i][:]) {
+					t.Errorf("elemento %d: got %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+			if f := accessorFormat(tt.acr); f != tt.format {
+				t.Errorf("accessorFormat = %v, want %v", f, tt.format)
+			}
+		})
+	}
+}
+
+func TestReadVecDequantizesUshortUVs(t *testing.T) {
+	doc := gltf.NewDocument()
+	acr := quantizedAccessor(doc, [][2]uint16{{0, 65535}, {32768, 13107}}, true)
+	got, err := readVec[[2]float32](doc, acr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]float32{{0, 1}, {32768.0 / 65535, 0.2}}
+	for i := range want {
+		if !nearFloats(got[i][:], want[i][:]) {
+			t.Errorf("elemento %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+// put é o inverso da leitura: o upload empacotado tem que devolver os
+// inteiros do arquivo.
+func TestAttributeFormatPutRoundTrip(t *testing.T) {
+	tests := []struct {
+		format AttributeFormat
+		v      float32
+		want   []byte
+	}{
+		{FormatShortNorm, 1, []byte{0xff, 0x7f}},
+		{FormatShortNorm, -1, []byte{0x01, 0x80}},
+		{FormatUshortNorm, 0.2, []byte{0x33, 0x33}},
+		{FormatByteNorm, -1, []byte{0x81}},
+		{FormatUbyteNorm, 1, []byte{0xff}},
+		{FormatShort, 300, []byte{0x2c, 0x01}},
+		{FormatUbyte, 300, []byte{0xff}}, // satura
+	}
+	for _, tt := range tests {
+		dst := make([]byte, tt.format.size())
+		tt.format.put(dst, tt.v)
+		if string(dst) != string(tt.want) {
+			t.Errorf("format %d, put(%v) = % x, want % x", tt.format, tt.v, dst, tt.want)
+		}
+	}
+
+	dst := make([]byte, 4)
+	FormatFloat.put(dst, 1.5)
+	if got := binary.LittleEndian.Uint32(dst); got != 0x3fc00000 {
+		t.Errorf("float put(1.5) = %#x", got)
+	}
+}
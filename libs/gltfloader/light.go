@@ -0,0 +1,92 @@
+package gltfloader
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/ext/lightspunctual"
+)
+
+// LightType é o tipo de uma luz KHR_lights_punctual.
+type LightType int
+
+const (
+	LightDirectional LightType = iota
+	LightPoint
+	LightSpot
+)
+
+// Light é uma luz KHR_lights_punctual instanciada por um nó. Luzes
+// direcionais e spots apontam para -Z do nó; World é o transform do nó.
+type Light struct {
+	Name      string
+	Node      int // índice em Nodes
+	Type      LightType
+	Color     [3]float32 // linear
+	Intensity float32    // lux (directional) ou candela (point/spot)
+	Range     float32    // 0 = sem limite
+	// Cones do spot, em radianos a partir do eixo -Z
+	InnerConeAngle float32
+	OuterConeAngle float32
+
+	World [16]float32
+}
+
+// documentLights retorna a lista de luzes declarada na raiz do documento.
+func documentLights(doc *gltf.Document) lightspunctual.Lights {
+	lights, _ := doc.Extensions[lightspunctual.ExtensionName].(lightspunctual.Lights)
+	return lights
+}
+
+// nodeLight retorna a luz referenciada pelo nó, se houver.
+func nodeLight(doc *gltf.Document, node *gltf.Node) (*lightspunctual.Light, error) {
+	idx, ok := node.Extensions[lightspunctual.ExtensionName].(lightspunctual.LightIndex)
+	if !ok {
+		return nil, nil
+	}
+	lights := documentLights(doc)
+	if int(idx) < 0 || int(idx) >= len(lights) {
+		return nil, fmt.Errorf("light index %d fora do range", idx)
+	}
+	return lights[idx], nil
+}
+
+// decodeLight converte a luz l, instanciada pelo nó nodeIdx.
+func decodeLight(l *lightspunctual.Light, nodeIdx int, world [16]float32) *Light {
+	c := l.ColorOrDefault()
+	out := &Light{
+		Name:      l.Name,
+		Node:      nodeIdx,
+		Color:     [3]float32{float32(c[0]), float32(c[1]), float32(c[2])},
+		Intensity: float32(l.IntensityOrDefault()),
+		World:     world,
+	}
+	if l.Range != nil && !math.IsInf(*l.Range, 0) {
+		out.Range = float32(*l.Range)
+	}
+
+	switch l.Type {
+	case lightspunctual.TypePoint:
+		out.Type = LightPoint
+	case lightspunctual.TypeSpot:
+		out.Type = LightSpot
+		out.OuterConeAngle = math.Pi / 4
+		if l.Spot != nil {
+			out.InnerConeAngle = float32(l.Spot.InnerConeAngle)
+			out.OuterConeAngle = float32(l.Spot.OuterConeAngleOrDefault())
+		}
+	default:
+		out.Type = LightDirectional
+	}
+	return out
+}
+
+func cloneLights(lights []*Light) []*Light {
+	out := make([]*Light, len(lights))
+	for i, l := range lights {
+		cp := *l
+		out[i] = &cp
+	}
+	return out
+}
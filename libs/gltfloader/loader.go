@@ -1,19 +1,6 @@
 package gltfloader
 
-import (
-	"bytes"
-	"fmt"
-	"image"
-	"image/draw"
-	_ "image/jpeg"
-	_ "image/png"
-	"math"
-
-	"github.com/go-gl/gl/v4.1-core/gl"
-	"github.com/joaqu1m/gogl-playground/libs/logger"
-	"github.com/qmuntal/gltf"
-	"github.com/qmuntal/gltf/modeler"
-)
+import "io/fs"
 
 // GLTFMesh contém os dados OpenGL prontos para renderizar.
 type GLTFMesh struct {
@@ -22,132 +9,148 @@ type GLTFMesh struct {
 	VertexCount int32
 	IndexCount  int32
 	HasIndices  bool
-	TextureID   uint32
-	HasTexture  bool
-	BaseColor   [4]float32
+	Mode        PrimitiveMode
+	Material    *Material
 	Transform   [16]float32 // Node world transform, column-major
+	Node        int         // índice em GLTFModel.Nodes, ou -1
+	Skin        int         // índice em GLTFModel.Skins, ou -1
+	Morph       *Morph      // nil quando a primitiva não tem morph targets
+	// InstanceCount é o número de cópias de EXT_mesh_gpu_instancing, ou 0
+	// para uma mesh desenhada uma vez só.
+	InstanceCount int32
+	// VariantMaterials é o material de cada variante mapeada pela primitiva
+	// (ver GLTFModel.SetVariant).
+	VariantMaterials map[int]*Material
+
+	vbo, ebo        uint32    // ebo é 0 sem índices
+	instanceVBO     uint32    // 0 sem instâncias
+	defaultMaterial *Material // material sem variante ativa
 }
 
 // GLTFModel agrupa todas as meshes carregadas de um arquivo glTF/GLB.
 type GLTFModel struct {
-	Meshes []*GLTFMesh
+	Meshes     []*GLTFMesh
+	Nodes      []*Node
+	Skins      []*Skin
+	Animations []*Animation
+	Materials  []*Material
+	Cameras    []*Camera
+	Lights     []*Light
+	// Textures mapeia o índice da textura glTF para o texture ID OpenGL.
+	// Os IDs vêm do cache compartilhado e podem ser usados por outros modelos.
+	Textures map[int]uint32
+	// Report lista os problemas encontrados ao carregar o arquivo.
+	Report *Report
+	// Variants são os nomes das variantes de material
+	// (KHR_materials_variants); troque com SetVariant.
+	Variants []string
+
+	// acquiredTextures tem uma entrada por referência tomada do cache de
+	// texturas, a ser devolvida no Release.
+	acquiredTextures []uint32
+	variant          int // variante ativa + 1; 0 com os materiais padrão
+	// animScratch é a saída dos samplers no ApplyAnimation, reaproveitada
+	// entre canais e frames.
+	animScratch []float32
 }
 
-// LoadGLB carrega um arquivo .glb/.gltf e cria os recursos OpenGL.
-// As posições são carregadas cruas, sem normalização. Os transforms dos nós
-// da scene graph são armazenados em cada GLTFMesh.Transform.
-func LoadGLB(filepath string) (*GLTFModel, error) {
-	doc, err := gltf.Open(filepath)
-	if err != nil {
-		return nil, fmt.Errorf("gltfloader: falha ao abrir %q: %w", filepath, err)
+// Texture resolve uma TextureRef de material para o texture ID OpenGL.
+// Retorna false se a referência é nil ou a textura não foi carregada.
+func (m *GLTFModel) Texture(ref *TextureRef) (uint32, bool) {
+	if ref == nil {
+		return 0, false
 	}
+	id, ok := m.Textures[ref.Index]
+	return id, ok
+}
 
-	textures, err := loadTextures(doc)
-	if err != nil {
-		return nil, fmt.Errorf("gltfloader: falha ao carregar texturas: %w", err)
+// Release libera todos os objetos OpenGL do modelo (VAOs e buffers) e
+// devolve suas texturas ao cache, que as deleta quando nenhum outro modelo
+// as usa. O modelo não pode mais ser desenhado depois disso; chamar de novo
+// não faz nada. Precisa do contexto GL.
+func (m *GLTFModel) Release() {
+	for _, mesh := range m.Meshes {
+		mesh.Release()
 	}
 
-	model := &GLTFModel{}
+	for _, id := range m.acquiredTextures {
+		releaseTexture(id)
+	}
+	m.acquiredTextures = nil
+	m.Textures = nil
+}
 
-	if len(doc.Scenes) > 0 {
-		// Percorre a scene graph a partir da cena ativa
-		sceneIdx := 0
-		if doc.Scene != nil {
-			sceneIdx = *doc.Scene
-		}
-		scene := doc.Scenes[sceneIdx]
-		for _, nodeIdx := range scene.Nodes {
-			if err := processNode(doc, nodeIdx, mat4fIdentity(), model, textures); err != nil {
-				return nil, err
-			}
-		}
-	} else {
-		// Fallback: sem cenas definidas, carrega todas as meshes com transform identidade
-		for _, mesh := range doc.Meshes {
-			for _, prim := range mesh.Primitives {
-				glMesh, err := loadPrimitive(doc, prim, textures)
-				if err != nil {
-					return nil, fmt.Errorf("gltfloader: falha ao carregar primitiva de %q: %w", mesh.Name, err)
-				}
-				glMesh.Transform = mat4fIdentity()
-				model.Meshes = append(model.Meshes, glMesh)
-			}
+// UpdateWorldTransforms propaga os transforms locais dos nós sujos pela
+// hierarquia, atualizando GLTFMesh.Transform, câmeras, luzes e as matrix
+// palettes das skins. Sem nenhum nó sujo não faz nada, então pode ser
+// chamado a cada frame.
+func (m *GLTFModel) UpdateWorldTransforms() {
+	if !updateWorldTransforms(m.Nodes, false) {
+		return
+	}
+	for _, mesh := range m.Meshes {
+		if mesh.Node >= 0 {
+			mesh.Transform = m.Nodes[mesh.Node].World
 		}
 	}
-
-	if len(model.Meshes) == 0 {
-		return nil, fmt.Errorf("gltfloader: nenhuma mesh encontrada em %q", filepath)
+	for _, cam := range m.Cameras {
+		cam.World = m.Nodes[cam.Node].World
+	}
+	for _, light := range m.Lights {
+		light.World = m.Nodes[light.Node].World
+	}
+	for _, skin := range m.Skins {
+		skin.updatePalette(m.Nodes)
 	}
-
-	return model, nil
 }
 
-// processNode percorre recursivamente a árvore de nós, acumulando transforms.
-func processNode(doc *gltf.Document, nodeIdx int, parentTransform [16]float32, model *GLTFModel, textures map[int]uint32) error {
-	if nodeIdx < 0 || nodeIdx >= len(doc.Nodes) {
-		return fmt.Errorf("gltfloader: node index %d fora do range", nodeIdx)
+// FindNode procura um nó pelo nome. Retorna nil se não existir; com nomes
+// repetidos, retorna o de menor índice.
+func (m *GLTFModel) FindNode(name string) *Node {
+	if idx := m.NodeIndex(name); idx >= 0 {
+		return m.Nodes[idx]
 	}
-	node := doc.Nodes[nodeIdx]
-
-	localTransform := nodeLocalTransform(node)
-	worldTransform := mat4fMul(parentTransform, localTransform)
-
-	if node.Mesh != nil {
-		meshIdx := *node.Mesh
-		if meshIdx < 0 || meshIdx >= len(doc.Meshes) {
-			return fmt.Errorf("gltfloader: mesh index %d fora do range", meshIdx)
-		}
-		mesh := doc.Meshes[meshIdx]
-		for _, prim := range mesh.Primitives {
-			glMesh, err := loadPrimitive(doc, prim, textures)
-			if err != nil {
-				return fmt.Errorf("gltfloader: falha ao carregar primitiva de %q: %w", mesh.Name, err)
-			}
-			glMesh.Name = mesh.Name
-			glMesh.Transform = worldTransform
-			model.Meshes = append(model.Meshes, glMesh)
+	return nil
+}
 
-			logger.Infof("mesh %q: node=%q transform=[%.3f, %.3f, %.3f, %.3f | %.3f, %.3f, %.3f, %.3f | %.3f, %.3f, %.3f, %.3f | %.3f, %.3f, %.3f, %.3f]",
-				mesh.Name, node.Name,
-				worldTransform[0], worldTransform[1], worldTransform[2], worldTransform[3],
-				worldTransform[4], worldTransform[5], worldTransform[6], worldTransform[7],
-				worldTransform[8], worldTransform[9], worldTransform[10], worldTransform[11],
-				worldTransform[12], worldTransform[13], worldTransform[14], worldTransform[15],
-			)
+// NodeIndex retorna o índice do nó com o nome dado, ou -1.
+func (m *GLTFModel) NodeIndex(name string) int {
+	for i, n := range m.Nodes {
+		if n.Name == name {
+			return i
 		}
 	}
+	return -1
+}
 
-	for _, childIdx := range node.Children {
-		if err := processNode(doc, childIdx, worldTransform, model, textures); err != nil {
-			return err
+// Camera retorna a câmera importada com o nome dado, ou nil.
+func (m *GLTFModel) Camera(name string) *Camera {
+	for _, c := range m.Cameras {
+		if c.Name == name {
+			return c
 		}
 	}
-
 	return nil
 }
 
-// nodeLocalTransform calcula a matriz de transformação local de um nó.
-func nodeLocalTransform(node *gltf.Node) [16]float32 {
-	// A biblioteca qmuntal/gltf inicializa Matrix com DefaultMatrix (identidade)
-	// no UnmarshalJSON, mesmo que o JSON não contenha "matrix". Então comparamos
-	// contra DefaultMatrix: se for diferente, o nó definiu uma matriz explícita.
-	if node.Matrix != gltf.DefaultMatrix {
-		var m [16]float32
-		for i, v := range node.Matrix {
-			m[i] = float32(v)
-		}
-		return m
+// LoadGLB carrega um arquivo .glb/.gltf e cria os recursos OpenGL.
+// É equivalente a DecodeFile seguido de Upload, e por isso precisa de um
+// contexto OpenGL ativo na thread atual.
+func LoadGLB(filepath string, opts Options) (*GLTFModel, error) {
+	data, err := DecodeFile(filepath, opts)
+	if err != nil {
+		return nil, err
 	}
+	return Upload(data)
+}
 
-	// Caso contrário, compor T * R * S
-	t := node.TranslationOrDefault()
-	r := node.RotationOrDefault()
-	s := node.ScaleOrDefault()
-	return composeTRS(
-		[3]float32{float32(t[0]), float32(t[1]), float32(t[2])},
-		[4]float32{float32(r[0]), float32(r[1]), float32(r[2]), float32(r[3])},
-		[3]float32{float32(s[0]), float32(s[1]), float32(s[2])},
-	)
+// LoadFS é como LoadGLB, mas lê o modelo e seus recursos externos de fsys.
+func LoadFS(fsys fs.FS, name string, opts Options) (*GLTFModel, error) {
+	data, err := DecodeFS(fsys, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return Upload(data)
 }
 
 // composeTRS constrói uma matriz 4x4 column-major a partir de translation,
@@ -209,271 +212,3 @@ func mat4fMul(a, b [16]float32) [16]float32 {
 	}
 	return r
 }
-
-// loadPrimitive converte uma primitiva glTF em VAO/VBO/EBO do OpenGL.
-// As posições são carregadas cruas, sem normalização.
-func loadPrimitive(doc *gltf.Document, prim *gltf.Primitive, textures map[int]uint32) (*GLTFMesh, error) {
-	// ---- Lê posições (obrigatório) ----
-	posAccessorIdx, ok := prim.Attributes[gltf.POSITION]
-	if !ok {
-		return nil, fmt.Errorf("primitiva sem POSITION")
-	}
-	posData, err := modeler.ReadPosition(doc, doc.Accessors[posAccessorIdx], nil)
-	if err != nil {
-		return nil, fmt.Errorf("erro lendo posições: %w", err)
-	}
-
-	// Log vertex bounds para debug
-	if len(posData) > 0 {
-		minP := posData[0]
-		maxP := posData[0]
-		for _, p := range posData[1:] {
-			for axis := 0; axis < 3; axis++ {
-				if p[axis] < minP[axis] {
-					minP[axis] = p[axis]
-				}
-				if p[axis] > maxP[axis] {
-					maxP[axis] = p[axis]
-				}
-			}
-		}
-		logger.Infof("  primitive %d verts: bounds min=(%.4f, %.4f, %.4f) max=(%.4f, %.4f, %.4f)",
-			len(posData),
-			minP[0], minP[1], minP[2],
-			maxP[0], maxP[1], maxP[2],
-		)
-	}
-
-	// ---- Lê normais (opcional) ----
-	var normalData [][3]float32
-	if normIdx, ok := prim.Attributes[gltf.NORMAL]; ok {
-		normalData, err = modeler.ReadNormal(doc, doc.Accessors[normIdx], nil)
-		if err != nil {
-			normalData = nil // fallback: calcula depois
-		}
-	}
-
-	// ---- Lê UVs (opcional) ----
-	var uvData [][2]float32
-	if uvIdx, ok := prim.Attributes[gltf.TEXCOORD_0]; ok {
-		uvData, _ = modeler.ReadTextureCoord(doc, doc.Accessors[uvIdx], nil)
-	}
-
-	// ---- Lê índices (opcional) ----
-	var indices []uint32
-	if prim.Indices != nil {
-		indData, err := modeler.ReadIndices(doc, doc.Accessors[*prim.Indices], nil)
-		if err != nil {
-			return nil, fmt.Errorf("erro lendo índices: %w", err)
-		}
-		indices = indData
-	}
-
-	// ---- Material ----
-	baseColor := [4]float32{0.8, 0.8, 0.8, 1.0}
-	var texID uint32
-	hasTexture := false
-
-	if prim.Material != nil {
-		mat := doc.Materials[*prim.Material]
-		if mat.PBRMetallicRoughness != nil {
-			pbr := mat.PBRMetallicRoughness
-			bc := pbr.BaseColorFactorOrDefault()
-			baseColor = [4]float32{float32(bc[0]), float32(bc[1]), float32(bc[2]), float32(bc[3])}
-
-			if pbr.BaseColorTexture != nil {
-				texIdx := pbr.BaseColorTexture.Index
-				if id, ok := textures[texIdx]; ok {
-					texID = id
-					hasTexture = true
-				}
-			}
-		}
-	}
-
-	// ---- Gera normais se não existirem ----
-	if normalData == nil {
-		normalData = generateFlatNormals(posData, indices)
-	}
-
-	// ---- Monta buffer interleaved: pos(3) + normal(3) + uv(2) = 8 floats ----
-	const floatsPerVert = 8
-	vertCount := len(posData)
-	buf := make([]float32, 0, vertCount*floatsPerVert)
-
-	for i := 0; i < vertCount; i++ {
-		// pos
-		buf = append(buf, posData[i][0], posData[i][1], posData[i][2])
-		// normal
-		if i < len(normalData) {
-			buf = append(buf, normalData[i][0], normalData[i][1], normalData[i][2])
-		} else {
-			buf = append(buf, 0, 1, 0)
-		}
-		// uv
-		if uvData != nil && i < len(uvData) {
-			buf = append(buf, uvData[i][0], uvData[i][1])
-		} else {
-			buf = append(buf, 0, 0)
-		}
-	}
-
-	// ---- Cria VAO/VBO/EBO ----
-	var vao, vbo uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.GenBuffers(1, &vbo)
-
-	gl.BindVertexArray(vao)
-
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(buf)*4, gl.Ptr(buf), gl.STATIC_DRAW)
-
-	const stride = floatsPerVert * 4 // 32 bytes
-
-	// location 0: posição
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, stride, gl.PtrOffset(0))
-	gl.EnableVertexAttribArray(0)
-
-	// location 1: normal
-	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, stride, gl.PtrOffset(3*4))
-	gl.EnableVertexAttribArray(1)
-
-	// location 2: texcoord
-	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, stride, gl.PtrOffset(6*4))
-	gl.EnableVertexAttribArray(2)
-
-	glMesh := &GLTFMesh{
-		VAO:        vao,
-		HasTexture: hasTexture,
-		TextureID:  texID,
-		BaseColor:  baseColor,
-	}
-
-	if len(indices) > 0 {
-		var ebo uint32
-		gl.GenBuffers(1, &ebo)
-		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
-		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
-		glMesh.HasIndices = true
-		glMesh.IndexCount = int32(len(indices))
-	} else {
-		glMesh.VertexCount = int32(vertCount)
-	}
-
-	gl.BindVertexArray(0)
-
-	return glMesh, nil
-}
-
-// loadTextures carrega todas as texturas do documento glTF e retorna um mapa texIdx -> OpenGL texture ID.
-func loadTextures(doc *gltf.Document) (map[int]uint32, error) {
-	result := make(map[int]uint32)
-
-	for i, tex := range doc.Textures {
-		if tex.Source == nil {
-			continue
-		}
-		imgIdx := *tex.Source
-		if imgIdx >= len(doc.Images) {
-			continue
-		}
-		img := doc.Images[imgIdx]
-
-		var imgBytes []byte
-
-		if img.BufferView != nil {
-			// Imagem embedded no buffer
-			bv := doc.BufferViews[*img.BufferView]
-			buf := doc.Buffers[bv.Buffer]
-			imgBytes = buf.Data[bv.ByteOffset : bv.ByteOffset+bv.ByteLength]
-		} else if img.IsEmbeddedResource() {
-			// Imagem embedded como data URI
-			data, err := img.MarshalData()
-			if err != nil {
-				continue
-			}
-			imgBytes = data
-		} else {
-			// Imagem externa (URI) não suportada neste loader
-			continue
-		}
-
-		texID, err := uploadImageToGL(imgBytes)
-		if err != nil {
-			continue
-		}
-		result[i] = texID
-	}
-
-	return result, nil
-}
-
-// uploadImageToGL decodifica bytes de imagem e sobe como textura OpenGL.
-func uploadImageToGL(data []byte) (uint32, error) {
-	img, _, err := image.Decode(bytes.NewReader(data))
-	if err != nil {
-		return 0, err
-	}
-
-	rgba := image.NewRGBA(img.Bounds())
-	draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
-
-	w := int32(rgba.Bounds().Dx())
-	h := int32(rgba.Bounds().Dy())
-
-	// Não fazemos flip vertical: glTexImage2D mapeia o primeiro pixel para texcoord (0,0),
-	// e glTF UV (0,0) é o topo-esquerda da imagem, que coincide com o primeiro pixel
-	// decodificado de PNG/JPEG. As convenções se cancelam.
-
-	var texID uint32
-	gl.GenTextures(1, &texID)
-	gl.BindTexture(gl.TEXTURE_2D, texID)
-
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, w, h, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
-	gl.GenerateMipmap(gl.TEXTURE_2D)
-
-	gl.BindTexture(gl.TEXTURE_2D, 0)
-	return texID, nil
-}
-
-// generateFlatNormals calcula normais por face (flat shading).
-func generateFlatNormals(positions [][3]float32, indices []uint32) [][3]float32 {
-	normals := make([][3]float32, len(positions))
-
-	processTriangle := func(i0, i1, i2 int) {
-		p0, p1, p2 := positions[i0], positions[i1], positions[i2]
-		e1 := [3]float32{p1[0] - p0[0], p1[1] - p0[1], p1[2] - p0[2]}
-		e2 := [3]float32{p2[0] - p0[0], p2[1] - p0[1], p2[2] - p0[2]}
-		n := [3]float32{
-			e1[1]*e2[2] - e1[2]*e2[1],
-			e1[2]*e2[0] - e1[0]*e2[2],
-			e1[0]*e2[1] - e1[1]*e2[0],
-		}
-		l := float32(math.Sqrt(float64(n[0]*n[0] + n[1]*n[1] + n[2]*n[2])))
-		if l > 0 {
-			n[0] /= l
-			n[1] /= l
-			n[2] /= l
-		}
-		normals[i0] = n
-		normals[i1] = n
-		normals[i2] = n
-	}
-
-	if len(indices) > 0 {
-		for i := 0; i+2 < len(indices); i += 3 {
-			processTriangle(int(indices[i]), int(indices[i+1]), int(indices[i+2]))
-		}
-	} else {
-		for i := 0; i+2 < len(positions); i += 3 {
-			processTriangle(i, i+1, i+2)
-		}
-	}
-
-	return normals
-}
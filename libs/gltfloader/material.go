@@ -0,0 +1,228 @@
+package gltfloader
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/ext/texturetransform"
+	"github.com/qmuntal/gltf/ext/unlit"
+)
+
+// emissiveStrengthExtension é o KHR_materials_emissive_strength, que a
+// qmuntal/gltf não registra; chega como json.RawMessage.
+const emissiveStrengthExtension = "KHR_materials_emissive_strength"
+
+// AlphaMode define como o alpha do base color é interpretado.
+type AlphaMode int
+
+const (
+	AlphaOpaque AlphaMode = iota
+	AlphaMask
+	AlphaBlend
+)
+
+// TextureRef referencia uma textura glTF e o conjunto de UV usado para
+// amostrá-la (TEXCOORD_<TexCoord>). Transform vem do KHR_texture_transform,
+// nil quando a referência não tem a extensão; o texCoord da extensão já está
+// aplicado em TexCoord.
+type TextureRef struct {
+	Index     int
+	TexCoord  int
+	Transform *UVTransform
+}
+
+// UVTransform é a transformação de UV do KHR_texture_transform. Rotation é
+// em radianos, no sentido anti-horário em UV.
+type UVTransform struct {
+	Offset   [2]float32
+	Rotation float32
+	Scale    [2]float32
+}
+
+// UVMatrix retorna a transformação de UV da referência como mat3
+// column-major (translação * rotação * escala), ou a identidade sem
+// KHR_texture_transform.
+func (r *TextureRef) UVMatrix() [9]float32 {
+	if r == nil || r.Transform == nil {
+		return [9]float32{1, 0, 0, 0, 1, 0, 0, 0, 1}
+	}
+	t := r.Transform
+	sin, cos := math.Sincos(float64(t.Rotation))
+	s, c := float32(sin), float32(cos)
+	return [9]float32{
+		c * t.Scale[0], -s * t.Scale[0], 0,
+		s * t.Scale[1], c * t.Scale[1], 0,
+		t.Offset[0], t.Offset[1], 1,
+	}
+}
+
+// Material é o material metallic-roughness do glTF 2.0. Texturas ausentes
+// ficam nil; os fatores já vêm com os defaults do spec aplicados.
+type Material struct {
+	Name string
+
+	BaseColorFactor          [4]float32
+	BaseColorTexture         *TextureRef
+	MetallicFactor           float32
+	RoughnessFactor          float32
+	MetallicRoughnessTexture *TextureRef // G = roughness, B = metallic
+
+	NormalTexture     *TextureRef
+	NormalScale       float32
+	OcclusionTexture  *TextureRef // R = occlusion
+	OcclusionStrength float32
+	EmissiveTexture   *TextureRef
+	EmissiveFactor    [3]float32
+	EmissiveStrength  float32 // KHR_materials_emissive_strength, 1 sem a extensão
+
+	// Unlit (KHR_materials_unlit) desenha a cor base sem iluminação.
+	Unlit bool
+
+	// Extensões PBR (ver materialext.go); nil quando ausentes. IOR vale
+	// DefaultIOR sem KHR_materials_ior.
+	Clearcoat    *Clearcoat
+	Sheen        *Sheen
+	Transmission *Transmission
+	Specular     *Specular
+	IOR          float32
+
+	AlphaMode   AlphaMode
+	AlphaCutoff float32
+	DoubleSided bool
+}
+
+// DefaultMaterial é usado por primitivas sem material. Mantém o cinza
+// dielétrico que o loader sempre usou, em vez do branco metálico do spec,
+// que fica preto sem iluminação de ambiente.
+func DefaultMaterial() *Material {
+	return &Material{
+		Name:              "default",
+		BaseColorFactor:   [4]float32{0.8, 0.8, 0.8, 1.0},
+		MetallicFactor:    0,
+		RoughnessFactor:   1,
+		NormalScale:       1,
+		OcclusionStrength: 1,
+		EmissiveStrength:  1,
+		IOR:               DefaultIOR,
+		AlphaMode:         AlphaOpaque,
+		AlphaCutoff:       0.5,
+	}
+}
+
+// decodeMaterials converte todos os materiais do documento, na mesma ordem.
+func decodeMaterials(doc *gltf.Document) []*Material {
+	materials := make([]*Material, len(doc.Materials))
+	for i, mat := range doc.Materials {
+		materials[i] = decodeMaterial(mat)
+	}
+	return materials
+}
+
+func decodeMaterial(mat *gltf.Material) *Material {
+	m := &Material{
+		Name:              mat.Name,
+		BaseColorFactor:   [4]float32{1, 1, 1, 1},
+		MetallicFactor:    1,
+		RoughnessFactor:   1,
+		NormalScale:       1,
+		OcclusionStrength: 1,
+		EmissiveStrength:  1,
+		IOR:               DefaultIOR,
+		AlphaCutoff:       float32(mat.AlphaCutoffOrDefault()),
+		DoubleSided:       mat.DoubleSided,
+		EmissiveFactor: [3]float32{
+			float32(mat.EmissiveFactor[0]),
+			float32(mat.EmissiveFactor[1]),
+			float32(mat.EmissiveFactor[2]),
+		},
+	}
+
+	switch mat.AlphaMode {
+	case gltf.AlphaMask:
+		m.AlphaMode = AlphaMask
+	case gltf.AlphaBlend:
+		m.AlphaMode = AlphaBlend
+	default:
+		m.AlphaMode = AlphaOpaque
+	}
+
+	if pbr := mat.PBRMetallicRoughness; pbr != nil {
+		bc := pbr.BaseColorFactorOrDefault()
+		m.BaseColorFactor = [4]float32{float32(bc[0]), float32(bc[1]), float32(bc[2]), float32(bc[3])}
+		m.MetallicFactor = float32(pbr.MetallicFactorOrDefault())
+		m.RoughnessFactor = float32(pbr.RoughnessFactorOrDefault())
+		m.BaseColorTexture = textureRef(pbr.BaseColorTexture)
+		m.MetallicRoughnessTexture = textureRef(pbr.MetallicRoughnessTexture)
+	}
+
+	if nt := mat.NormalTexture; nt != nil && nt.Index != nil {
+		m.NormalTexture = newTextureRef(*nt.Index, nt.TexCoord, nt.Extensions)
+		m.NormalScale = float32(nt.ScaleOrDefault())
+	}
+	if ot := mat.OcclusionTexture; ot != nil && ot.Index != nil {
+		m.OcclusionTexture = newTextureRef(*ot.Index, ot.TexCoord, ot.Extensions)
+		m.OcclusionStrength = float32(ot.StrengthOrDefault())
+	}
+	m.EmissiveTexture = textureRef(mat.EmissiveTexture)
+
+	_, m.Unlit = mat.Extensions[unlit.ExtensionName]
+
+	var es struct {
+		EmissiveStrength *float64 `json:"emissiveStrength"`
+	}
+	if decodeExtension(mat.Extensions, emissiveStrengthExtension, &es) && es.EmissiveStrength != nil {
+		m.EmissiveStrength = float32(*es.EmissiveStrength)
+	}
+
+	decodeMaterialExtensions(mat, m)
+
+	return m
+}
+
+// decodeExtension decodifica a extensão name em v. Extensões que a
+// qmuntal/gltf não conhece chegam como json.RawMessage; as demais são
+// reconvertidas via JSON. Retorna false se a extensão não existe ou não
+// decodifica.
+func decodeExtension(ext gltf.Extensions, name string, v any) bool {
+	raw, ok := ext[name]
+	if !ok {
+		return false
+	}
+	data, ok := raw.(json.RawMessage)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(raw); err != nil {
+			return false
+		}
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+func textureRef(info *gltf.TextureInfo) *TextureRef {
+	if info == nil {
+		return nil
+	}
+	return newTextureRef(info.Index, info.TexCoord, info.Extensions)
+}
+
+// newTextureRef monta a referência aplicando o KHR_texture_transform das
+// extensões do texture info, se houver.
+func newTextureRef(index, texCoord int, ext gltf.Extensions) *TextureRef {
+	ref := &TextureRef{Index: index, TexCoord: texCoord}
+
+	tt, ok := ext[texturetransform.ExtensionName].(*texturetransform.TextureTranform)
+	if !ok {
+		return ref
+	}
+	scale := tt.ScaleOrDefault()
+	ref.Transform = &UVTransform{
+		Offset:   [2]float32{float32(tt.Offset[0]), float32(tt.Offset[1])},
+		Rotation: float32(tt.Rotation),
+		Scale:    [2]float32{float32(scale[0]), float32(scale[1])},
+	}
+	if tt.TexCoord != nil {
+		ref.TexCoord = *tt.TexCoord
+	}
+	return ref
+}
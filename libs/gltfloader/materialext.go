@@ -0,0 +1,124 @@
+package gltfloader
+
+import "github.com/qmuntal/gltf"
+
+// Extensões PBR de material. Nenhuma delas é registrada pela qmuntal/gltf,
+// então são lidas do JSON cru com decodeExtension.
+const (
+	clearcoatExtension    = "KHR_materials_clearcoat"
+	sheenExtension        = "KHR_materials_sheen"
+	transmissionExtension = "KHR_materials_transmission"
+	iorExtension          = "KHR_materials_ior"
+	specularExtension     = "KHR_materials_specular"
+)
+
+// DefaultIOR é o índice de refração do spec quando o material não tem
+// KHR_materials_ior (F0 = 0.04).
+const DefaultIOR = 1.5
+
+// Clearcoat é a camada de verniz do KHR_materials_clearcoat, um segundo lobo
+// especular dielétrico sobre o material base.
+type Clearcoat struct {
+	Factor           float32
+	Texture          *TextureRef // R = factor
+	Roughness        float32
+	RoughnessTexture *TextureRef // G = roughness
+	NormalTexture    *TextureRef
+	NormalScale      float32
+}
+
+// Sheen é o brilho aveludado de tecidos do KHR_materials_sheen.
+type Sheen struct {
+	ColorFactor      [3]float32
+	ColorTexture     *TextureRef // RGB = cor
+	Roughness        float32
+	RoughnessTexture *TextureRef // A = roughness
+}
+
+// Transmission é a fração de luz que atravessa a superfície
+// (KHR_materials_transmission).
+type Transmission struct {
+	Factor  float32
+	Texture *TextureRef // R = factor
+}
+
+// Specular é o KHR_materials_specular: intensidade e cor da reflexão
+// especular dielétrica.
+type Specular struct {
+	Factor       float32
+	Texture      *TextureRef // A = factor
+	ColorFactor  [3]float32
+	ColorTexture *TextureRef // RGB = cor
+}
+
+// decodeMaterialExtensions preenche em m as extensões PBR de mat.
+func decodeMaterialExtensions(mat *gltf.Material, m *Material) {
+	var cc struct {
+		ClearcoatFactor           float64             `json:"clearcoatFactor"`
+		ClearcoatTexture          *gltf.TextureInfo   `json:"clearcoatTexture"`
+		ClearcoatRoughnessFactor  float64             `json:"clearcoatRoughnessFactor"`
+		ClearcoatRoughnessTexture *gltf.TextureInfo   `json:"clearcoatRoughnessTexture"`
+		ClearcoatNormalTexture    *gltf.NormalTexture `json:"clearcoatNormalTexture"`
+	}
+	if decodeExtension(mat.Extensions, clearcoatExtension, &cc) {
+		m.Clearcoat = &Clearcoat{
+			Factor:           float32(cc.ClearcoatFactor),
+			Texture:          textureRef(cc.ClearcoatTexture),
+			Roughness:        float32(cc.ClearcoatRoughnessFactor),
+			RoughnessTexture: textureRef(cc.ClearcoatRoughnessTexture),
+			NormalScale:      1,
+		}
+		if nt := cc.ClearcoatNormalTexture; nt != nil && nt.Index != nil {
+			m.Clearcoat.NormalTexture = newTextureRef(*nt.Index, nt.TexCoord, nt.Extensions)
+			m.Clearcoat.NormalScale = float32(nt.ScaleOrDefault())
+		}
+	}
+
+	var sh struct {
+		SheenColorFactor      [3]float64        `json:"sheenColorFactor"`
+		SheenColorTexture     *gltf.TextureInfo `json:"sheenColorTexture"`
+		SheenRoughnessFactor  float64           `json:"sheenRoughnessFactor"`
+		SheenRoughnessTexture *gltf.TextureInfo `json:"sheenRoughnessTexture"`
+	}
+	if decodeExtension(mat.Extensions, sheenExtension, &sh) {
+		m.Sheen = &Sheen{
+			ColorFactor:      [3]float32{float32(sh.SheenColorFactor[0]), float32(sh.SheenColorFactor[1]), float32(sh.SheenColorFactor[2])},
+			ColorTexture:     textureRef(sh.SheenColorTexture),
+			Roughness:        float32(sh.SheenRoughnessFactor),
+			RoughnessTexture: textureRef(sh.SheenRoughnessTexture),
+		}
+	}
+
+	var tr struct {
+		TransmissionFactor  float64           `json:"transmissionFactor"`
+		TransmissionTexture *gltf.TextureInfo `json:"transmissionTexture"`
+	}
+	if decodeExtension(mat.Extensions, transmissionExtension, &tr) {
+		m.Transmission = &Transmission{
+			Factor:  float32(tr.TransmissionFactor),
+			Texture: textureRef(tr.TransmissionTexture),
+		}
+	}
+
+	ior := struct {
+		IOR float64 `json:"ior"`
+	}{DefaultIOR}
+	if decodeExtension(mat.Extensions, iorExtension, &ior) {
+		m.IOR = float32(ior.IOR)
+	}
+
+	sp := struct {
+		SpecularFactor       float64           `json:"specularFactor"`
+		SpecularTexture      *gltf.TextureInfo `json:"specularTexture"`
+		SpecularColorFactor  [3]float64        `json:"specularColorFactor"`
+		SpecularColorTexture *gltf.TextureInfo `json:"specularColorTexture"`
+	}{SpecularFactor: 1, SpecularColorFactor: [3]float64{1, 1, 1}}
+	if decodeExtension(mat.Extensions, specularExtension, &sp) {
+		m.Specular = &Specular{
+			Factor:       float32(sp.SpecularFactor),
+			Texture:      textureRef(sp.SpecularTexture),
+			ColorFactor:  [3]float32{float32(sp.SpecularColorFactor[0]), float32(sp.SpecularColorFactor[1]), float32(sp.SpecularColorFactor[2])},
+			ColorTexture: textureRef(sp.SpecularColorTexture),
+		}
+	}
+}
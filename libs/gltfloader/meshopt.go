@@ -0,0 +1,645 @@
+package gltfloader
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/qmuntal/gltf"
+)
+
+// meshoptExtension é o EXT_meshopt_compression. Buffer views comprimidos
+// apontam para os dados comprimidos na extensão; o buffer do próprio view
+// costuma ser um fallback sem dados.
+const meshoptExtension = "EXT_meshopt_compression"
+
+// meshoptView é a extensão de um buffer view comprimido.
+type meshoptView struct {
+	Buffer     int    `json:"buffer"`
+	ByteOffset int    `json:"byteOffset"`
+	ByteLength int    `json:"byteLength"`
+	ByteStride int    `json:"byteStride"`
+	Count      int    `json:"count"`
+	Mode       string `json:"mode"`
+	Filter     string `json:"filter"`
+}
+
+// meshoptBuffer é a extensão de um buffer: Fallback marca buffers que só
+// existem para leitores sem suporte à extensão e podem não ter dados.
+type meshoptBuffer struct {
+	Fallback bool `json:"fallback"`
+}
+
+var errMeshoptData = errors.New("dados comprimidos inválidos")
+
+// decompressMeshopt decodifica os buffer views comprimidos com
+// EXT_meshopt_compression. Cada view decodificado ganha um buffer próprio
+// e perde a extensão, então o resto do decode lê os dados normalmente.
+func decompressMeshopt(doc *gltf.Document) error {
+	for i, bv := range doc.BufferViews {
+		var ext meshoptView
+		if !decodeExtension(bv.Extensions, meshoptExtension, &ext) {
+			continue
+		}
+
+		if ext.Buffer < 0 || ext.Buffer >= len(doc.Buffers) {
+			return fmt.Errorf("buffer view %d: buffer %d fora do range", i, ext.Buffer)
+		}
+		src := doc.Buffers[ext.Buffer].Data
+		if ext.ByteOffset < 0 || ext.ByteLength < 0 || ext.ByteOffset+ext.ByteLength > len(src) {
+			return fmt.Errorf("buffer view %d: dados comprimidos fora do buffer %d", i, ext.Buffer)
+		}
+
+		data, err := decodeMeshopt(src[ext.ByteOffset:ext.ByteOffset+ext.ByteLength], ext)
+		if err != nil {
+			return fmt.Errorf("buffer view %d: %w", i, err)
+		}
+
+		doc.Buffers = append(doc.Buffers, &gltf.Buffer{ByteLength: len(data), Data: data})
+		bv.Buffer = len(doc.Buffers) - 1
+		bv.ByteOffset = 0
+		bv.ByteLength = len(data)
+		delete(bv.Extensions, meshoptExtension)
+	}
+	return nil
+}
+
+// isMeshoptFallback informa se o buffer é um fallback do
+// EXT_meshopt_compression, que pode vir sem URI nem dados.
+func isMeshoptFallback(b *gltf.Buffer) bool {
+	var ext meshoptBuffer
+	return decodeExtension(b.Extensions, meshoptExtension, &ext) && ext.Fallback
+}
+
+// patchMeshoptFallbacks dá um data URI vazio aos buffers de fallback sem
+// uri. O decoder da qmuntal/gltf recusa buffers sem uri, e o gltfpack gera
+// esses fallbacks.
+func patchMeshoptFallbacks(raw []byte) []byte {
+	return patchBuffers(raw, func(_ int, b map[string]json.RawMessage) bool {
+		if _, ok := b["uri"]; ok {
+			return false
+		}
+		var exts map[string]json.RawMessage
+		var ext meshoptBuffer
+		if json.Unmarshal(b["extensions"], &exts) != nil || json.Unmarshal(exts[meshoptExtension], &ext) != nil || !ext.Fallback {
+			return false
+		}
+		b["uri"], _ = json.Marshal(emptyDataURI)
+		return true
+	})
+}
+
+// decodeMeshopt decodifica um buffer view no modo e filtro de ext.
+func decodeMeshopt(src []byte, ext meshoptView) ([]byte, error) {
+	if ext.Count < 0 || ext.ByteStride <= 0 {
+		return nil, fmt.Errorf("count %d / byteStride %d inválidos", ext.Count, ext.ByteStride)
+	}
+	out := make([]byte, ext.Count*ext.ByteStride)
+
+	switch ext.Mode {
+	case "ATTRIBUTES":
+		if ext.ByteStride%4 != 0 || ext.ByteStride > 256 {
+			return nil, fmt.Errorf("byteStride %d inválido para ATTRIBUTES", ext.ByteStride)
+		}
+		if err := decodeVertexBuffer(out, ext.Count, ext.ByteStride, src); err != nil {
+			return nil, err
+		}
+	case "TRIANGLES":
+		if ext.Count%3 != 0 {
+			return nil, fmt.Errorf("count %d não é múltiplo de 3", ext.Count)
+		}
+		if ext.ByteStride != 2 && ext.ByteStride != 4 {
+			return nil, fmt.Errorf("byteStride %d inválido para índices", ext.ByteStride)
+		}
+		if err := decodeIndexBuffer(out, ext.Count, ext.ByteStride, src); err != nil {
+			return nil, err
+		}
+	case "INDICES":
+		if ext.ByteStride != 2 && ext.ByteStride != 4 {
+			return nil, fmt.Errorf("byteStride %d inválido para índices", ext.ByteStride)
+		}
+		if err := decodeIndexSequence(out, ext.Count, ext.ByteStride, src); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("modo %q não suportado", ext.Mode)
+	}
+
+	switch ext.Filter {
+	case "", "NONE":
+	case "OCTAHEDRAL":
+		if ext.Mode != "ATTRIBUTES" || (ext.ByteStride != 4 && ext.ByteStride != 8) {
+			return nil, fmt.Errorf("filtro OCTAHEDRAL inválido com byteStride %d", ext.ByteStride)
+		}
+		filterOctahedral(out, ext.Count, ext.ByteStride)
+	case "QUATERNION":
+		if ext.Mode != "ATTRIBUTES" || ext.ByteStride != 8 {
+			return nil, fmt.Errorf("filtro QUATERNION inválido com byteStride %d", ext.ByteStride)
+		}
+		filterQuaternion(out, ext.Count)
+	case "EXPONENTIAL":
+		if ext.Mode != "ATTRIBUTES" {
+			return nil, fmt.Errorf("filtro EXPONENTIAL inválido no modo %s", ext.Mode)
+		}
+		filterExponential(out)
+	default:
+		return nil, fmt.Errorf("filtro %q não suportado", ext.Filter)
+	}
+
+	return out, nil
+}
+
+// ---- Atributos ----
+//
+// Os vértices vêm em blocos de até 256. Em cada bloco, cada byte do vértice
+// é um fluxo próprio de deltas (zigzag) em relação ao vértice anterior,
+// guardado em grupos de 16 valores com 0, 2, 4 ou 8 bits cada.
+
+const (
+	vertexHeader        = 0xa0
+	vertexBlockSizeByte = 8192
+	vertexBlockMaxSize  = 256
+	byteGroupSize       = 16
+	vertexTailMinSize   = 32
+)
+
+func vertexBlockSize(stride int) int {
+	n := (vertexBlockSizeByte / stride) &^ (byteGroupSize - 1)
+	return min(n, vertexBlockMaxSize)
+}
+
+func decodeVertexBuffer(dst []byte, count, stride int, src []byte) error {
+	if len(src) < 1+stride {
+		return errMeshoptData
+	}
+	if src[0] != vertexHeader {
+		return fmt.Errorf("versão do codec de vértices não suportada: 0x%02x", src[0])
+	}
+
+	// O primeiro "vértice anterior" fica no fim do stream
+	last := make([]byte, stride)
+	copy(last, src[len(src)-stride:])
+
+	tail := max(stride, vertexTailMinSize)
+	data := src[1:]
+	if len(data) < tail {
+		return errMeshoptData
+	}
+	body := data[:len(data)-tail]
+
+	blockSize := vertexBlockSize(stride)
+	buf := make([]byte, vertexBlockMaxSize)
+	pos := 0
+	for start := 0; start < count; start += blockSize {
+		n := min(blockSize, count-start)
+		aligned := (n + byteGroupSize - 1) &^ (byteGroupSize - 1)
+		block := dst[start*stride:]
+
+		for k := 0; k < stride; k++ {
+			var err error
+			if pos, err = decodeBytes(body, pos, buf[:aligned]); err != nil {
+				return err
+			}
+			p := last[k]
+			for i := 0; i < n; i++ {
+				v := buf[i]
+				p += (v >> 1) ^ -(v & 1) // unzigzag
+				block[i*stride+k] = p
+			}
+		}
+		copy(last, block[(n-1)*stride:n*stride])
+	}
+
+	if pos != len(body) {
+		return errMeshoptData
+	}
+	return nil
+}
+
+// decodeBytes lê len(out) bytes (múltiplo de 16) a partir de data[pos]:
+// um cabeçalho de 2 bits por grupo seguido dos grupos.
+func decodeBytes(data []byte, pos int, out []byte) (int, error) {
+	groups := len(out) / byteGroupSize
+	headerSize := (groups + 3) / 4
+	if pos+headerSize > len(data) {
+		return 0, errMeshoptData
+	}
+	header := data[pos : pos+headerSize]
+	pos += headerSize
+
+	for g := 0; g < groups; g++ {
+		bits := (header[g/4] >> ((g % 4) * 2)) & 3
+		group := out[g*byteGroupSize : (g+1)*byteGroupSize]
+
+		switch bits {
+		case 0:
+			clear(group)
+		case 3:
+			if pos+byteGroupSize > len(data) {
+				return 0, errMeshoptData
+			}
+			copy(group, data[pos:])
+			pos += byteGroupSize
+		default:
+			// 2 ou 4 bits por valor, do bit mais alto para o mais baixo. O
+			// valor máximo é sentinela: o byte real vem depois do grupo.
+			width := uint(2 * bits)
+			packed := byteGroupSize * int(width) / 8
+			if pos+packed > len(data) {
+				return 0, errMeshoptData
+			}
+			extra := pos + packed
+			sentinel := byte(1<<width - 1)
+			for i := range group {
+				b := data[pos+i*int(width)/8]
+				shift := 8 - width - uint(i*int(width)%8)
+				v := (b >> shift) & sentinel
+				if v == sentinel {
+					if extra >= len(data) {
+						return 0, errMeshoptData
+					}
+					v = data[extra]
+					extra++
+				}
+				group[i] = v
+			}
+			pos = extra
+		}
+	}
+	return pos, nil
+}
+
+// ---- Triângulos ----
+//
+// Cada triângulo tem um byte de código que o descreve em relação a uma FIFO
+// de arestas e uma FIFO de vértices recentes; índices novos vêm como deltas
+// em varint no fluxo de dados.
+
+const indexHeader = 0xe0
+
+func decodeIndexBuffer(dst []byte, count, stride int, src []byte) error {
+	if len(src) < 1+count/3+16 {
+		return errMeshoptData
+	}
+	if src[0]&0xf0 != indexHeader {
+		return fmt.Errorf("codec de índices inválido: 0x%02x", src[0])
+	}
+	version := src[0] & 0x0f
+	if version > 1 {
+		return fmt.Errorf("versão do codec de índices não suportada: %d", version)
+	}
+
+	var edgeFifo [16][2]uint32
+	var vertexFifo [16]uint32
+	for i := range edgeFifo {
+		edgeFifo[i] = [2]uint32{math.MaxUint32, math.MaxUint32}
+		vertexFifo[i] = math.MaxUint32
+	}
+	edgeOff, vertexOff := 0, 0
+	pushVertex := func(v uint32, cond bool) {
+		vertexFifo[vertexOff] = v
+		if cond {
+			vertexOff = (vertexOff + 1) & 15
+		}
+	}
+	pushEdge := func(a, b uint32) {
+		edgeFifo[edgeOff] = [2]uint32{a, b}
+		edgeOff = (edgeOff + 1) & 15
+	}
+
+	var next, last uint32
+	fecMax := 15
+	if version >= 1 {
+		fecMax = 13
+	}
+
+	code := src[1 : 1+count/3]
+	dataEnd := len(src) - 16
+	codeAux := src[dataEnd:]
+	d := &varintReader{data: src[:dataEnd], pos: 1 + count/3}
+
+	for t := 0; t < count/3; t++ {
+		if d.pos > dataEnd {
+			return errMeshoptData
+		}
+		codeTri := code[t]
+
+		var a, b, c uint32
+		switch {
+		case codeTri < 0xf0:
+			fe := int(codeTri >> 4)
+			edge := edgeFifo[(edgeOff-1-fe)&15]
+			a, b = edge[0], edge[1]
+			fec := int(codeTri & 15)
+
+			if fec < fecMax {
+				if fec == 0 {
+					c = next
+					next++
+				} else {
+					c = vertexFifo[(vertexOff-1-fec)&15]
+				}
+				pushVertex(c, fec == 0)
+			} else {
+				if fec != 15 {
+					// 13 e 14 são os deltas -1 e +1 do último índice livre
+					last += uint32(fec - (fec ^ 3))
+				} else {
+					last += d.delta()
+				}
+				c = last
+				pushVertex(c, true)
+			}
+			pushEdge(c, b)
+			pushEdge(a, c)
+
+		case codeTri < 0xfe:
+			aux := codeAux[codeTri&15]
+			feb, fec := int(aux>>4), int(aux&15)
+
+			a = next
+			next++
+			if feb == 0 {
+				b = next
+				next++
+			} else {
+				b = vertexFifo[(vertexOff-feb)&15]
+			}
+			if fec == 0 {
+				c = next
+				next++
+			} else {
+				c = vertexFifo[(vertexOff-fec)&15]
+			}
+			pushVertex(a, true)
+			pushVertex(b, feb == 0)
+			pushVertex(c, fec == 0)
+			pushEdge(b, a)
+			pushEdge(c, b)
+			pushEdge(a, c)
+
+		default:
+			aux, ok := d.byte()
+			if !ok {
+				return errMeshoptData
+			}
+			fea := 15
+			if codeTri == 0xfe {
+				fea = 0
+			}
+			feb, fec := int(aux>>4), int(aux&15)
+			if aux == 0 {
+				next = 0
+			}
+
+			if fea == 0 {
+				a = next
+				next++
+			}
+			if feb == 0 {
+				b = next
+				next++
+			} else {
+				b = vertexFifo[(vertexOff-feb)&15]
+			}
+			if fec == 0 {
+				c = next
+				next++
+			} else {
+				c = vertexFifo[(vertexOff-fec)&15]
+			}
+			if fea == 15 {
+				last += d.delta()
+				a = last
+			}
+			if feb == 15 {
+				last += d.delta()
+				b = last
+			}
+			if fec == 15 {
+				last += d.delta()
+				c = last
+			}
+			pushVertex(a, true)
+			pushVertex(b, feb == 0 || feb == 15)
+			pushVertex(c, fec == 0 || fec == 15)
+			pushEdge(b, a)
+			pushEdge(c, b)
+			pushEdge(a, c)
+		}
+
+		if d.err {
+			return errMeshoptData
+		}
+		putIndex(dst, stride, t*3, a)
+		putIndex(dst, stride, t*3+1, b)
+		putIndex(dst, stride, t*3+2, c)
+	}
+
+	if d.pos != dataEnd {
+		return errMeshoptData
+	}
+	return nil
+}
+
+// ---- Sequência de índices ----
+
+const sequenceHeader = 0xd0
+
+func decodeIndexSequence(dst []byte, count, stride int, src []byte) error {
+	if len(src) < 1+count+4 {
+		return errMeshoptData
+	}
+	if src[0]&0xf0 != sequenceHeader {
+		return fmt.Errorf("codec de sequência inválido: 0x%02x", src[0])
+	}
+	if src[0]&0x0f > 1 {
+		return fmt.Errorf("versão do codec de sequência não suportada: %d", src[0]&0x0f)
+	}
+
+	dataEnd := len(src) - 4
+	d := &varintReader{data: src[:dataEnd], pos: 1}
+	var last [2]uint32
+	for i := 0; i < count; i++ {
+		if d.pos >= dataEnd {
+			return errMeshoptData
+		}
+		v := d.varint()
+		if d.err {
+			return errMeshoptData
+		}
+		// O bit baixo escolhe qual das duas bases o delta usa
+		base := v & 1
+		v >>= 1
+		last[base] += (v >> 1) ^ -(v & 1)
+		putIndex(dst, stride, i, last[base])
+	}
+
+	if d.pos != dataEnd {
+		return errMeshoptData
+	}
+	return nil
+}
+
+func putIndex(dst []byte, stride, i int, v uint32) {
+	if stride == 2 {
+		binary.LittleEndian.PutUint16(dst[i*2:], uint16(v))
+	} else {
+		binary.LittleEndian.PutUint32(dst[i*4:], v)
+	}
+}
+
+// varintReader lê os varints do meshopt. Leituras além do fim marcam err
+// em vez de entrar em pânico.
+type varintReader struct {
+	data []byte
+	pos  int
+	err  bool
+}
+
+func (r *varintReader) byte() (byte, bool) {
+	if r.pos >= len(r.data) {
+		r.err = true
+		return 0, false
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, true
+}
+
+// varint lê um inteiro de 7 bits por byte, com no máximo 5 bytes.
+func (r *varintReader) varint() uint32 {
+	lead, ok := r.byte()
+	if !ok || lead < 128 {
+		return uint32(lead)
+	}
+	result := uint32(lead & 127)
+	shift := uint(7)
+	for i := 0; i < 4; i++ {
+		group, ok := r.byte()
+		if !ok {
+			return 0
+		}
+		result |= uint32(group&127) << shift
+		shift += 7
+		if group < 128 {
+			break
+		}
+	}
+	return result
+}
+
+// delta lê um varint em zigzag, somado ao último índice livre.
+func (r *varintReader) delta() uint32 {
+	v := r.varint()
+	return (v >> 1) ^ -(v & 1)
+}
+
+// ---- Filtros ----
+
+// filterOctahedral reconstrói vetores unitários codificados em octaedro
+// (normais e tangentes), em int8 (stride 4) ou int16 (stride 8). O quarto
+// componente não é alterado.
+func filterOctahedral(data []byte, count, stride int) {
+	size := stride / 4
+	maxv := float32(int(1)<<(size*8-1) - 1)
+	get := func(off int) float32 {
+		if size == 1 {
+			return float32(int8(data[off]))
+		}
+		return float32(int16(binary.LittleEndian.Uint16(data[off:])))
+	}
+	set := func(off int, v int) {
+		if size == 1 {
+			data[off] = byte(int8(v))
+		} else {
+			binary.LittleEndian.PutUint16(data[off:], uint16(int16(v)))
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		base := i * stride
+		x := get(base)
+		y := get(base + size)
+		z := get(base+2*size) - abs32(x) - abs32(y)
+
+		// Corrige as coordenadas do octaedro para z < 0
+		t := min(z, 0)
+		if x >= 0 {
+			x += t
+		} else {
+			x -= t
+		}
+		if y >= 0 {
+			y += t
+		} else {
+			y -= t
+		}
+
+		l := float32(math.Sqrt(float64(x*x + y*y + z*z)))
+		s := maxv / l
+		set(base, roundSigned(x*s))
+		set(base+size, roundSigned(y*s))
+		set(base+2*size, roundSigned(z*s))
+	}
+}
+
+// filterQuaternion reconstrói quaternions guardados como os 3 menores
+// componentes em int16; o quarto componente carrega o índice do maior e a
+// escala.
+func filterQuaternion(data []byte, count int) {
+	const scale = 1 / math.Sqrt2
+	for i := 0; i < count; i++ {
+		var q [4]int16
+		for c := range q {
+			q[c] = int16(binary.LittleEndian.Uint16(data[i*8+c*2:]))
+		}
+
+		sf := int32(q[3]) | 3
+		ss := float32(scale) / float32(sf)
+		x := float32(q[0]) * ss
+		y := float32(q[1]) * ss
+		z := float32(q[2]) * ss
+		ww := 1 - x*x - y*y - z*z
+		w := float32(math.Sqrt(float64(max(ww, 0))))
+
+		qc := int(q[3] & 3)
+		out := [4]int{}
+		out[(qc+1)&3] = roundSigned(x * 32767)
+		out[(qc+2)&3] = roundSigned(y * 32767)
+		out[(qc+3)&3] = roundSigned(z * 32767)
+		out[qc] = int(w*32767 + 0.5)
+		for c, v := range out {
+			binary.LittleEndian.PutUint16(data[i*8+c*2:], uint16(int16(v)))
+		}
+	}
+}
+
+// filterExponential converte valores com mantissa de 24 bits e expoente de
+// 8 bits em float32.
+func filterExponential(data []byte) {
+	for i := 0; i+4 <= len(data); i += 4 {
+		v := binary.LittleEndian.Uint32(data[i:])
+		m := int32(v<<8) >> 8
+		e := int32(v) >> 24
+		f := math.Float32frombits(uint32(e+127)<<23) * float32(m)
+		binary.LittleEndian.PutUint32(data[i:], math.Float32bits(f))
+	}
+}
+
+func abs32(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// roundSigned arredonda para o inteiro mais próximo, afastando de zero.
+func roundSigned(v float32) int {
+	if v >= 0 {
+		return int(v + 0.5)
+	}
+	return int(v - 0.5)
+}
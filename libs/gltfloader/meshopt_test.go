@@ -0,0 +1,197 @@
+package gltfloader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func u16s(vs ...uint16) []byte {
+	out := make([]byte, 2*len(vs))
+	for i, v := range vs {
+		binary.LittleEndian.PutUint16(out[2*i:], v)
+	}
+	return out
+}
+
+func u32s(vs ...uint32) []byte {
+	out := make([]byte, 4*len(vs))
+	for i, v := range vs {
+		binary.LittleEndian.PutUint32(out[4*i:], v)
+	}
+	return out
+}
+
+// zeros é o fim do stream de vértices: 32 bytes cujo final é o primeiro
+// vértice, todo zero nestes casos.
+var zeros = make([]byte, 32)
+
+// Os streams de ATTRIBUTES foram montados à mão seguindo o encoder de
+// referência: deltas em zigzag a partir do primeiro vértice (guardado no fim
+// do stream) e, em cada grupo de 16, a menor codificação entre 0, 2, 4 e 8
+// bits.
+func TestDecodeMeshopt(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+		ext  meshoptView
+		want []byte
+	}{
+		{
+			// {px, py, pz uint16; nu, nv uint8; tx, ty uint16} de um quad.
+			// Os grupos saem em 2 bits (0x01) com sentinelas, ou zerados (0x00).
+			name: "ATTRIBUTES 2 bits",
+			src: concat(
+				[]byte{0xa0},
+				[]byte{0x01, 0x3f, 0x00, 0x00, 0x00, 0x58, 0x57, 0x58}, // px lo: 0, 44, 0, 44
+				[]byte{0x01, 0x26, 0x00, 0x00, 0x00},                   // px hi
+				[]byte{0x01, 0x0c, 0x00, 0x00, 0x00, 0x58},             // py lo
+				[]byte{0x01, 0x08, 0x00, 0x00, 0x00},                   // py hi
+				[]byte{0x00, 0x00, 0x00, 0x00},                         // pz, nu, nv
+				[]byte{0x01, 0x3f, 0x00, 0x00, 0x00, 0x17, 0x18, 0x17}, // tx lo: 0, 0xf4, 0, 0xf4
+				[]byte{0x01, 0x26, 0x00, 0x00, 0x00},                   // tx hi
+				[]byte{0x01, 0x0c, 0x00, 0x00, 0x00, 0x17},             // ty lo
+				[]byte{0x01, 0x08, 0x00, 0x00, 0x00},                   // ty hi
+				zeros,
+			),
+			ext: meshoptView{Mode: "ATTRIBUTES", Count: 4, ByteStride: 12},
+			want: concat(
+				u16s(0, 0, 0), []byte{0, 0}, u16s(0, 0),
+				u16s(300, 0, 0), []byte{0, 0}, u16s(500, 0),
+				u16s(0, 300, 0), []byte{0, 0}, u16s(0, 500),
+				u16s(300, 300, 0), []byte{0, 0}, u16s(500, 500),
+			),
+		},
+		{
+			// Primeiro byte de cada vértice sobe de 3 em 3: deltas 6 em zigzag,
+			// num grupo de 4 bits (0x02)
+			name: "ATTRIBUTES 4 bits",
+			src: concat(
+				[]byte{0xa0},
+				[]byte{0x02, 0x06, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66},
+				[]byte{0x00, 0x00, 0x00},
+				zeros,
+			),
+			ext: meshoptView{Mode: "ATTRIBUTES", Count: 16, ByteStride: 4},
+			want: func() []byte {
+				out := make([]byte, 16*4)
+				for i := 0; i < 16; i++ {
+					out[i*4] = byte(3 * i)
+				}
+				return out
+			}(),
+		},
+		// TRIANGLES v0 e INDICES são do tests.cpp do meshoptimizer
+		{
+			name: "TRIANGLES v0",
+			src: []byte{
+				0xe0, 0xf0, 0x10, 0xfe, 0xff, 0xf0, 0x0c, 0xff, 0x02, 0x02, 0x02, 0x00, 0x76, 0x87, 0x56, 0x67,
+				0x78, 0xa9, 0x86, 0x65, 0x89, 0x68, 0x98, 0x01, 0x69, 0x00, 0x00,
+			},
+			ext:  meshoptView{Mode: "TRIANGLES", Count: 12, ByteStride: 4},
+			want: u32s(0, 1, 2, 2, 1, 3, 4, 6, 5, 7, 8, 9),
+		},
+		{
+			// Montado à mão: um triângulo de 3 índices livres (0xff) e dois
+			// que reaproveitam a última aresta com os deltas +1 (fec 14) e
+			// -1 (fec 13), que só existem na versão 1. A tabela codeaux no
+			// fim é a fixa do encoder.
+			name: "TRIANGLES v1",
+			src: []byte{
+				0xe1, 0xff, 0x0e, 0x0d, 0xff, 0x14, 0x02, 0x02,
+				0x00, 0x76, 0x87, 0x56, 0x67, 0x78, 0xa9, 0x86, 0x65, 0x89, 0x68, 0x98, 0x01, 0x69, 0x00, 0x00,
+			},
+			ext:  meshoptView{Mode: "TRIANGLES", Count: 9, ByteStride: 2},
+			want: u16s(10, 11, 12, 10, 12, 13, 10, 13, 12),
+		},
+		{
+			name: "INDICES",
+			src:  []byte{0xd1, 0x00, 0x04, 0xcd, 0x01, 0x04, 0x07, 0x98, 0x1f, 0x00, 0x00, 0x00, 0x00},
+			ext:  meshoptView{Mode: "INDICES", Count: 6, ByteStride: 4},
+			want: u32s(0, 1, 51, 2, 49, 1000),
+		},
+		{
+			// Os dados do filtro OCTAHEDRAL int8 de TestMeshoptFilters, agora
+			// passando pelo codec de vértices
+			name: "OCTAHEDRAL int8",
+			src: concat(
+				[]byte{0xa0},
+				[]byte{0x01, 0x07, 0x00, 0x00, 0x00, 0x1e},             // x: 0, 0, 255, 14
+				[]byte{0x01, 0x3f, 0x00, 0x00, 0x00, 0x8b, 0x8c, 0xfd}, // y: 1, 187, 1, 130
+				[]byte{0x00},                         // z: 127
+				[]byte{0x01, 0x26, 0x00, 0x00, 0x00}, // w: 0, 1, 0, 1
+				concat(make([]byte, 28), []byte{0, 1, 127, 0}),
+			),
+			ext: meshoptView{Mode: "ATTRIBUTES", Count: 4, ByteStride: 4, Filter: "OCTAHEDRAL"},
+			want: []byte{
+				0, 1, 127, 0,
+				0, 159, 82, 1,
+				255, 1, 127, 0,
+				1, 130, 241, 1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeMeshopt(tt.src, tt.ext)
+			if err != nil {
+				t.Fatalf("decodeMeshopt: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("got  % x\nwant % x", got, tt.want)
+			}
+		})
+	}
+}
+
+// Filtros aplicados direto nos dados já decodificados, com os vetores do
+// tests.cpp do meshoptimizer.
+func TestMeshoptFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter func([]byte)
+		data   []byte
+		want   []byte
+	}{
+		{
+			name:   "OCTAHEDRAL int8",
+			filter: func(d []byte) { filterOctahedral(d, 4, 4) },
+			data:   []byte{0, 1, 127, 0, 0, 187, 127, 1, 255, 1, 127, 0, 14, 130, 127, 1},
+			want:   []byte{0, 1, 127, 0, 0, 159, 82, 1, 255, 1, 127, 0, 1, 130, 241, 1},
+		},
+		{
+			name:   "OCTAHEDRAL int16",
+			filter: func(d []byte) { filterOctahedral(d, 4, 8) },
+			data:   u16s(0, 1, 2047, 0, 0, 1870, 2047, 1, 2017, 1, 2047, 0, 14, 1300, 2047, 1),
+			want:   u16s(0, 16, 32767, 0, 0, 32621, 3088, 1, 32764, 16, 471, 0, 307, 28541, 16093, 1),
+		},
+		{
+			name:   "QUATERNION",
+			filter: func(d []byte) { filterQuaternion(d, 4) },
+			data:   u16s(0, 1, 0, 0x7fc, 0, 1870, 0, 0x7fd, 2017, 1, 0, 0x7fe, 14, 1300, 0, 0x7ff),
+			want:   u16s(32767, 0, 11, 0, 0, 25013, 0, 21166, 11, 0, 23504, 22830, 158, 14715, 0, 29277),
+		},
+		{
+			name:   "EXPONENTIAL",
+			filter: filterExponential,
+			data:   u32s(0, 0xff000003, 0x02fffff7, 0xfe7fffff),
+			want:   u32s(0, 0x3fc00000, 0xc2100000, 0x49fffffe), // 0, 1.5, -36, 2097151.75
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter(tt.data)
+			if !bytes.Equal(tt.data, tt.want) {
+				t.Errorf("got  % x\nwant % x", tt.data, tt.want)
+			}
+		})
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package gltfloader
+
+import (
+	"fmt"
+
+	"github.com/qmuntal/gltf"
+)
+
+// MorphTarget guarda os deltas de um morph target (blend shape).
+// Cada slice é nil quando o target não desloca aquele atributo.
+type MorphTarget struct {
+	Positions [][3]float32
+	Normals   [][3]float32
+	Tangents  [][3]float32
+}
+
+// Morph guarda o estado de CPU necessário para aplicar morph targets numa
+// GLTFMesh: os deltas, os pesos atuais e uma cópia do vertex buffer original.
+// A mistura é feita na CPU e reenviada ao VBO só quando os pesos mudam.
+type Morph struct {
+	Targets []MorphTarget
+	Weights []float32
+
+	base    []float32 // vertex buffer interleaved sem deformação
+	current []float32 // vertex buffer com os deltas aplicados
+	vbo     uint32
+	dirty   bool
+}
+
+// decodeMorphTargets lê os deltas POSITION/NORMAL/TANGENT de cada target da
+// primitiva.
+func decodeMorphTargets(doc *gltf.Document, prim *gltf.Primitive, vertCount int) ([]MorphTarget, error) {
+	targets := make([]MorphTarget, len(prim.Targets))
+	for i, attrs := range prim.Targets {
+		if idx, ok := attrs[gltf.POSITION]; ok {
+			pos, err := readVec[[3]float32](doc, doc.Accessors[idx])
+			if err != nil {
+				return nil, fmt.Errorf("target %d: erro lendo POSITION: %w", i, err)
+			}
+			if len(pos) != vertCount {
+				return nil, fmt.Errorf("target %d: POSITION com %d elementos, esperado %d", i, len(pos), vertCount)
+			}
+			targets[i].Positions = pos
+		}
+		if idx, ok := attrs[gltf.NORMAL]; ok {
+			nrm, err := readVec[[3]float32](doc, doc.Accessors[idx])
+			if err != nil {
+				return nil, fmt.Errorf("target %d: erro lendo NORMAL: %w", i, err)
+			}
+			if len(nrm) != vertCount {
+				return nil, fmt.Errorf("target %d: NORMAL com %d elementos, esperado %d", i, len(nrm), vertCount)
+			}
+			targets[i].Normals = nrm
+		}
+		if idx, ok := attrs[gltf.TANGENT]; ok {
+			// Deltas de tangente são VEC3, sem handedness
+			tan, err := readVec[[3]float32](doc, doc.Accessors[idx])
+			if err != nil {
+				return nil, fmt.Errorf("target %d: erro lendo TANGENT: %w", i, err)
+			}
+			if len(tan) != vertCount {
+				return nil, fmt.Errorf("target %d: TANGENT com %d elementos, esperado %d", i, len(tan), vertCount)
+			}
+			targets[i].Tangents = tan
+		}
+	}
+	return targets, nil
+}
+
+// defaultMorphWeights resolve os pesos iniciais: node.weights tem prioridade
+// sobre mesh.weights, e na ausência dos dois todos os pesos são zero.
+func defaultMorphWeights(node *gltf.Node, mesh *gltf.Mesh, count int) []float32 {
+	weights := make([]float32, count)
+	src := mesh.Weights
+	if node != nil && len(node.Weights) > 0 {
+		src = node.Weights
+	}
+	for i := 0; i < count && i < len(src); i++ {
+		weights[i] = float32(src[i])
+	}
+	return weights
+}
+
+// SetMorphWeights altera os pesos dos morph targets. O resultado é aplicado
+// no próximo ApplyMorph. Pesos além do número de targets são ignorados.
+func (m *GLTFMesh) SetMorphWeights(weights []float32) {
+	if m.Morph == nil {
+		return
+	}
+	for i := 0; i < len(weights) && i < len(m.Morph.Weights); i++ {
+		if m.Morph.Weights[i] != weights[i] {
+			m.Morph.Weights[i] = weights[i]
+			m.Morph.dirty = true
+		}
+	}
+}
+
+// blend recalcula o vertex buffer deformado a partir do base e dos pesos.
+func (mo *Morph) blend() {
+	copy(mo.current, mo.base)
+	for t, target := range mo.Targets {
+		w := mo.Weights[t]
+		if w == 0 {
+			continue
+		}
+		for v, d := range target.Positions {
+			off := v * floatsPerVertex
+			mo.current[off] += w * d[0]
+			mo.current[off+1] += w * d[1]
+			mo.current[off+2] += w * d[2]
+		}
+		for v, d := range target.Normals {
+			off := v*floatsPerVertex + 3
+			mo.current[off] += w * d[0]
+			mo.current[off+1] += w * d[1]
+			mo.current[off+2] += w * d[2]
+		}
+		for v, d := range target.Tangents {
+			off := v*floatsPerVertex + 16
+			mo.current[off] += w * d[0]
+			mo.current[off+1] += w * d[1]
+			mo.current[off+2] += w * d[2]
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package gltfloader
+
+import (
+	"math"
+
+	"github.com/qmuntal/gltf"
+)
+
+// Node é um nó da scene graph glTF com seu transform local em TRS.
+// O índice do nó em ModelData.Nodes/GLTFModel.Nodes é o mesmo do documento.
+//
+// Para mover um nó use os setters, que marcam o nó como sujo; o World dele e
+// dos descendentes é recalculado no próximo UpdateWorldTransforms. Quem
+// alterar Translation/Rotation/Scale diretamente precisa chamar MarkDirty.
+type Node struct {
+	Name        string
+	Parent      int // -1 para nós raiz
+	Children    []int
+	Mesh        int   // índice da mesh glTF, ou -1
+	Meshes      []int // primitivas do nó, índices em Meshes do modelo
+	Translation [3]float32
+	Rotation    [4]float32 // quaternion xyzw
+	Scale       [3]float32
+	World       [16]float32 // calculado por UpdateWorldTransforms, column-major
+
+	dirty bool
+}
+
+// LocalTransform retorna T * R * S do nó.
+func (n *Node) LocalTransform() [16]float32 {
+	return composeTRS(n.Translation, n.Rotation, n.Scale)
+}
+
+// SetTranslation altera a translação local do nó.
+func (n *Node) SetTranslation(t [3]float32) {
+	n.Translation = t
+	n.dirty = true
+}
+
+// SetRotation altera a rotação local do nó (quaternion xyzw).
+func (n *Node) SetRotation(q [4]float32) {
+	n.Rotation = q
+	n.dirty = true
+}
+
+// SetScale altera a escala local do nó.
+func (n *Node) SetScale(s [3]float32) {
+	n.Scale = s
+	n.dirty = true
+}
+
+// SetLocalTransform decompõe uma matriz column-major em TRS. A matriz não
+// pode ter shear.
+func (n *Node) SetLocalTransform(m [16]float32) {
+	n.Translation, n.Rotation, n.Scale = decomposeTRS(m)
+	n.dirty = true
+}
+
+// MarkDirty força o recálculo do World do nó e dos descendentes.
+func (n *Node) MarkDirty() {
+	n.dirty = true
+}
+
+// decodeNodes copia todos os nós do documento, resolvendo os pais.
+func decodeNodes(doc *gltf.Document) []*Node {
+	nodes := make([]*Node, len(doc.Nodes))
+	for i, n := range doc.Nodes {
+		node := &Node{
+			Name:     n.Name,
+			Parent:   -1,
+			Children: append([]int(nil), n.Children...),
+			Mesh:     -1,
+		}
+		if n.Mesh != nil {
+			node.Mesh = *n.Mesh
+		}
+		if n.Matrix != gltf.DefaultMatrix {
+			// O spec exige que "matrix" seja decomponível em TRS
+			node.Translation, node.Rotation, node.Scale = decomposeTRS(nodeLocalTransform(n))
+		} else {
+			t := n.TranslationOrDefault()
+			r := n.RotationOrDefault()
+			s := n.ScaleOrDefault()
+			node.Translation = [3]float32{float32(t[0]), float32(t[1]), float32(t[2])}
+			node.Rotation = [4]float32{float32(r[0]), float32(r[1]), float32(r[2]), float32(r[3])}
+			node.Scale = [3]float32{float32(s[0]), float32(s[1]), float32(s[2])}
+		}
+		nodes[i] = node
+	}
+
+	for i, node := range nodes {
+		for _, child := range node.Children {
+			if child >= 0 && child < len(nodes) {
+				nodes[child].Parent = i
+			}
+		}
+	}
+
+	updateWorldTransforms(nodes, true)
+	return nodes
+}
+
+// updateWorldTransforms recalcula Node.World partindo das raízes. Sem force,
+// só as subárvores com algum nó sujo são recalculadas. Retorna se algum World
+// mudou.
+func updateWorldTransforms(nodes []*Node, force bool) bool {
+	changed := false
+
+	var visit func(idx int, parent [16]float32, parentChanged bool)
+	visit = func(idx int, parent [16]float32, parentChanged bool) {
+		node := nodes[idx]
+		recompute := force || parentChanged || node.dirty
+		if recompute {
+			node.World = mat4fMul(parent, node.LocalTransform())
+			node.dirty = false
+			changed = true
+		}
+		for _, child := range node.Children {
+			if child >= 0 && child < len(nodes) && nodes[child].Parent == idx {
+				visit(child, node.World, recompute)
+			}
+		}
+	}
+
+	for i, node := range nodes {
+		if node.Parent == -1 {
+			visit(i, mat4fIdentity(), false)
+		}
+	}
+	return changed
+}
+
+// cloneNodes faz uma cópia profunda dos nós, para que cada GLTFModel
+// possa alterar seus transforms sem afetar o ModelData de origem.
+func cloneNodes(nodes []*Node) []*Node {
+	out := make([]*Node, len(nodes))
+	for i, n := range nodes {
+		c := *n
+		c.Children = append([]int(nil), n.Children...)
+		c.Meshes = append([]int(nil), n.Meshes...)
+		out[i] = &c
+	}
+	return out
+}
+
+// decomposeTRS separa uma matriz afim column-major em translation,
+// rotation (quaternion xyzw) e scale. Assume que não há shear.
+func decomposeTRS(m [16]float32) (t [3]float32, q [4]float32, s [3]float32) {
+	t = [3]float32{m[12], m[13], m[14]}
+
+	colLen := func(c int) float32 {
+		x, y, z := m[c*4], m[c*4+1], m[c*4+2]
+		return float32(math.Sqrt(float64(x*x + y*y + z*z)))
+	}
+	s = [3]float32{colLen(0), colLen(1), colLen(2)}
+
+	// Determinante negativo indica reflexão: joga o sinal no eixo X
+	det := m[0]*(m[5]*m[10]-m[9]*m[6]) -
+		m[4]*(m[1]*m[10]-m[9]*m[2]) +
+		m[8]*(m[1]*m[6]-m[5]*m[2])
+	if det < 0 {
+		s[0] = -s[0]
+	}
+
+	var r [9]float32 // rotação pura, column-major 3x3
+	for c := 0; c < 3; c++ {
+		inv := float32(0)
+		if s[c] != 0 {
+			inv = 1 / s[c]
+		}
+		r[c*3] = m[c*4] * inv
+		r[c*3+1] = m[c*4+1] * inv
+		r[c*3+2] = m[c*4+2] * inv
+	}
+
+	// Conversão matriz -> quaternion (Shepperd)
+	m00, m11, m22 := r[0], r[4], r[8]
+	m01, m02 := r[3], r[6]
+	m10, m12 := r[1], r[7]
+	m20, m21 := r[2], r[5]
+
+	trace := m00 + m11 + m22
+	switch {
+	case trace > 0:
+		k := float32(math.Sqrt(float64(trace+1))) * 2
+		q = [4]float32{(m21 - m12) / k, (m02 - m20) / k, (m10 - m01) / k, 0.25 * k}
+	case m00 > m11 && m00 > m22:
+		k := float32(math.Sqrt(float64(1+m00-m11-m22))) * 2
+		q = [4]float32{0.25 * k, (m01 + m10) / k, (m02 + m20) / k, (m21 - m12) / k}
+	case m11 > m22:
+		k := float32(math.Sqrt(float64(1+m11-m00-m22))) * 2
+		q = [4]float32{(m01 + m10) / k, 0.25 * k, (m12 + m21) / k, (m02 - m20) / k}
+	default:
+		k := float32(math.Sqrt(float64(1+m22-m00-m11))) * 2
+		q = [4]float32{(m02 + m20) / k, (m12 + m21) / k, 0.25 * k, (m10 - m01) / k}
+	}
+	return t, q, s
+}
@@ -0,0 +1,188 @@
+package gltfloader
+
+import "math"
+
+// normalSplitEpsilon é a tolerância (em cosseno) para dois cantos de um
+// mesmo vértice compartilharem a normal sem duplicar o vértice.
+const normalSplitEpsilon = 1e-4
+
+// generateNormals calcula as normais de uma primitiva de triângulos sem
+// NORMAL, conforme opts. Quando cantos de um mesmo vértice precisam de normais
+// diferentes (modo flat ou arestas acima do crease angle) o vértice é
+// duplicado e a primitiva vira uma lista de triângulos indexada.
+func generateNormals(md *MeshData, opts Options) {
+	tris := triangleList(md.Mode, md.Indices, len(md.Positions))
+	triCount := len(tris) / 3
+
+	// Normal não normalizada de cada face: o módulo é o dobro da área
+	faceNormals := make([][3]float32, triCount)
+	valid := make([]bool, triCount)
+	for t := 0; t < triCount; t++ {
+		i0, i1, i2 := tris[t*3], tris[t*3+1], tris[t*3+2]
+		if int(i0) >= len(md.Positions) || int(i1) >= len(md.Positions) || int(i2) >= len(md.Positions) {
+			continue
+		}
+		p0, p1, p2 := md.Positions[i0], md.Positions[i1], md.Positions[i2]
+		faceNormals[t] = cross3(sub3(p1, p0), sub3(p2, p0))
+		valid[t] = true
+	}
+
+	// Normal de cada canto (3 por triângulo)
+	corner := make([][3]float32, len(tris))
+	if opts.Normals == NormalsFlat {
+		for t := 0; t < triCount; t++ {
+			n := normalize3(faceNormals[t])
+			corner[t*3], corner[t*3+1], corner[t*3+2] = n, n, n
+		}
+	} else {
+		smoothCornerNormals(md.Positions, tris, faceNormals, valid, opts.CreaseAngle, corner)
+	}
+
+	// Resolve uma normal por vértice, duplicando onde os cantos divergem
+	normals := make([][3]float32, len(md.Positions))
+	assigned := make([]bool, len(md.Positions))
+	splits := make(map[uint32][]uint32) // vértice original -> cópias
+	remap := make([]uint32, len(md.Positions))
+	for i := range remap {
+		remap[i] = uint32(i)
+	}
+	newTris := make([]uint32, len(tris))
+	split := false
+
+	for c, v := range tris {
+		newTris[c] = v
+		if int(v) >= len(md.Positions) {
+			continue
+		}
+		n := corner[c]
+		if !assigned[v] {
+			normals[v] = n
+			assigned[v] = true
+			continue
+		}
+		if dot3(normals[v], n) >= 1-normalSplitEpsilon {
+			continue
+		}
+
+		found := false
+		for _, copyIdx := range splits[v] {
+			if dot3(normals[copyIdx], n) >= 1-normalSplitEpsilon {
+				newTris[c] = copyIdx
+				found = true
+				break
+			}
+		}
+		if !found {
+			copyIdx := uint32(len(remap))
+			remap = append(remap, v)
+			normals = append(normals, n)
+			splits[v] = append(splits[v], copyIdx)
+			newTris[c] = copyIdx
+		}
+		split = true
+	}
+
+	// Vértices sem face ficam com a normal padrão do upload
+	for v := range assigned {
+		if !assigned[v] {
+			normals[v] = [3]float32{0, 1, 0}
+		}
+	}
+
+	if split {
+		remapVertices(md, remap)
+		md.Indices = newTris
+		md.Mode = ModeTriangles
+	}
+	md.Normals = normals
+}
+
+// smoothCornerNormals calcula a normal de cada canto como a soma das normais
+// das faces que tocam a mesma posição, ponderadas pela área (módulo da normal
+// não normalizada) e pelo ângulo do canto. Com crease > 0, só entram faces
+// cujo ângulo com a face do canto não passa do crease. Os cantos são agrupados
+// por posição, então vértices duplicados em costuras de UV também suavizam.
+func smoothCornerNormals(positions [][3]float32, tris []uint32, faceNormals [][3]float32, valid []bool, crease float32, out [][3]float32) {
+	type cornerRef struct {
+		c int
+		w float32
+	}
+
+	groups := make(map[[3]float32][]cornerRef)
+	for c, v := range tris {
+		t := c / 3
+		if !valid[t] {
+			continue
+		}
+		p := positions[v]
+		prev := positions[tris[t*3+(c+2)%3]]
+		next := positions[tris[t*3+(c+1)%3]]
+		angle := angleBetween(sub3(next, p), sub3(prev, p))
+		groups[p] = append(groups[p], cornerRef{c: c, w: angle})
+	}
+
+	cosCrease := float32(math.Cos(float64(crease)))
+	unit := make([][3]float32, len(faceNormals))
+	for t := range faceNormals {
+		unit[t] = normalize3(faceNormals[t])
+	}
+
+	for _, group := range groups {
+		for _, ref := range group {
+			ft := ref.c / 3
+			var sum [3]float32
+			for _, other := range group {
+				ot := other.c / 3
+				if crease > 0 && dot3(unit[ft], unit[ot]) < cosCrease {
+					continue
+				}
+				fn := faceNormals[ot]
+				sum[0] += fn[0] * other.w
+				sum[1] += fn[1] * other.w
+				sum[2] += fn[2] * other.w
+			}
+			out[ref.c] = normalize3(sum)
+		}
+	}
+}
+
+func sub3(a, b [3]float32) [3]float32 {
+	return [3]float32{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func cross3(a, b [3]float32) [3]float32 {
+	return [3]float32{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func dot3(a, b [3]float32) float32 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func normalize3(v [3]float32) [3]float32 {
+	l := float32(math.Sqrt(float64(dot3(v, v))))
+	if l == 0 {
+		return v
+	}
+	return [3]float32{v[0] / l, v[1] / l, v[2] / l}
+}
+
+// angleBetween retorna o ângulo (radianos) entre dois vetores, ou 0 se algum
+// for degenerado.
+func angleBetween(a, b [3]float32) float32 {
+	la := float32(math.Sqrt(float64(dot3(a, a))))
+	lb := float32(math.Sqrt(float64(dot3(b, b))))
+	if la == 0 || lb == 0 {
+		return 0
+	}
+	c := dot3(a, b) / (la * lb)
+	if c > 1 {
+		c = 1
+	} else if c < -1 {
+		c = -1
+	}
+	return float32(math.Acos(float64(c)))
+}
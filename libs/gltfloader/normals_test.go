@@ -0,0 +1,64 @@
+package gltfloader
+
+import (
+	"math"
+	"testing"
+)
+
+// foldedMesh são dois triângulos dobrados a 90° na aresta 0-1: o primeiro
+// com normal +Z e o segundo com normal -Y.
+func foldedMesh() *MeshData {
+	return &MeshData{
+		Positions: [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, -1}},
+		Indices:   []uint32{0, 1, 2, 1, 0, 3},
+		Mode:      ModeTriangles,
+	}
+}
+
+func TestGenerateNormalsCreaseAngle(t *testing.T) {
+	h := float32(math.Sqrt2 / 2)
+	up, down := [3]float32{0, 0, 1}, [3]float32{0, -1, 0}
+	smooth := [3]float32{0, -h, h}
+
+	tests := []struct {
+		name      string
+		opts      Options
+		vertices  int
+		want0     [3]float32 // normal nos cantos da aresta, no primeiro triângulo
+		want1     [3]float32 // idem, no segundo
+		wantSolo0 [3]float32 // vértice só do primeiro triângulo
+	}{
+		{"smooth sem crease", Options{Normals: NormalsSmooth}, 4, smooth, smooth, up},
+		{"crease abaixo da dobra", Options{Normals: NormalsSmooth, CreaseAngle: math.Pi / 3}, 6, up, down, up},
+		{"crease acima da dobra", Options{Normals: NormalsSmooth, CreaseAngle: 2 * math.Pi / 3}, 4, smooth, smooth, up},
+		{"flat", Options{Normals: NormalsFlat}, 6, up, down, up},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := foldedMesh()
+			generateNormals(md, tt.opts)
+
+			if len(md.Positions) != tt.vertices || len(md.Normals) != tt.vertices {
+				t.Fatalf("%d vértices / %d normais, want %d", len(md.Positions), len(md.Normals), tt.vertices)
+			}
+			n := func(corner int) [3]float32 { return md.Normals[md.Indices[corner]] }
+			for _, c := range []int{0, 1} {
+				if !nearVec3(n(c), tt.want0) {
+					t.Errorf("triângulo 0, canto %d: got %v, want %v", c, n(c), tt.want0)
+				}
+			}
+			for _, c := range []int{3, 4} {
+				if !nearVec3(n(c), tt.want1) {
+					t.Errorf("triângulo 1, canto %d: got %v, want %v", c-3, n(c), tt.want1)
+				}
+			}
+			if !nearVec3(n(2), tt.wantSolo0) {
+				t.Errorf("vértice 2: got %v, want %v", n(2), tt.wantSolo0)
+			}
+		})
+	}
+}
+
+func nearVec3(a, b [3]float32) bool {
+	return nearFloats(a[:], b[:])
+}
@@ -0,0 +1,26 @@
+package gltfloader
+
+// NormalMode define como gerar normais para primitivas sem NORMAL.
+type NormalMode int
+
+const (
+	// NormalsSmooth faz a média das normais das faces vizinhas, ponderada por
+	// área e ângulo, respeitando Options.CreaseAngle.
+	NormalsSmooth NormalMode = iota
+	// NormalsFlat dá a cada face sua própria normal (faceted).
+	NormalsFlat
+)
+
+// Options configura o decode de um modelo.
+type Options struct {
+	Normals NormalMode
+	// CreaseAngle (radianos) é o ângulo entre faces acima do qual uma aresta
+	// fica dura no modo smooth, duplicando os vértices dela. 0 desativa.
+	CreaseAngle float32
+}
+
+// DefaultOptions retorna as opções usadas por NewModel: normais suaves, sem
+// crease angle.
+func DefaultOptions() Options {
+	return Options{Normals: NormalsSmooth}
+}
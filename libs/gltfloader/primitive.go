@@ -0,0 +1,82 @@
+package gltfloader
+
+import "github.com/qmuntal/gltf"
+
+// PrimitiveMode é a topologia de uma primitiva glTF.
+type PrimitiveMode int
+
+const (
+	ModeTriangles PrimitiveMode = iota
+	ModePoints
+	ModeLines
+	ModeLineLoop
+	ModeLineStrip
+	ModeTriangleStrip
+	ModeTriangleFan
+)
+
+// IsTriangles informa se a topologia forma faces (lista, strip ou fan).
+func (p PrimitiveMode) IsTriangles() bool {
+	return p == ModeTriangles || p == ModeTriangleStrip || p == ModeTriangleFan
+}
+
+func convertMode(m gltf.PrimitiveMode) PrimitiveMode {
+	switch m {
+	case gltf.PrimitivePoints:
+		return ModePoints
+	case gltf.PrimitiveLines:
+		return ModeLines
+	case gltf.PrimitiveLineLoop:
+		return ModeLineLoop
+	case gltf.PrimitiveLineStrip:
+		return ModeLineStrip
+	case gltf.PrimitiveTriangleStrip:
+		return ModeTriangleStrip
+	case gltf.PrimitiveTriangleFan:
+		return ModeTriangleFan
+	}
+	return ModeTriangles
+}
+
+// triangleList expande a topologia em uma lista de triângulos (3 índices por
+// face), para o processamento em CPU. indices nil significa primitiva não
+// indexada com vertCount vértices. Strips alternam a ordem para manter o
+// winding, como manda o spec. Retorna nil para pontos e linhas.
+func triangleList(mode PrimitiveMode, indices []uint32, vertCount int) []uint32 {
+	if !mode.IsTriangles() {
+		return nil
+	}
+
+	n := vertCount
+	if indices != nil {
+		n = len(indices)
+	}
+	at := func(i int) uint32 {
+		if indices != nil {
+			return indices[i]
+		}
+		return uint32(i)
+	}
+
+	var out []uint32
+	switch mode {
+	case ModeTriangles:
+		out = make([]uint32, 0, n-n%3)
+		for i := 0; i+2 < n; i += 3 {
+			out = append(out, at(i), at(i+1), at(i+2))
+		}
+	case ModeTriangleStrip:
+		for i := 0; i+2 < n; i++ {
+			if i%2 == 0 {
+				out = append(out, at(i), at(i+1), at(i+2))
+			} else {
+				out = append(out, at(i+1), at(i), at(i+2))
+			}
+		}
+	case ModeTriangleFan:
+		for i := 1; i+1 < n; i++ {
+			out = append(out, at(i), at(i+1), at(0))
+		}
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package gltfloader
+
+import (
+	"fmt"
+
+	"github.com/joaqu1m/gogl-playground/libs/logger"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// Skin descreve um esqueleto glTF: os nós usados como joints e suas
+// inverse bind matrices. Palette guarda, por joint, World(joint) * IBM,
+// que é o que o vertex shader consome no linear-blend skinning.
+type Skin struct {
+	Name                string
+	Joints              []int // índices em Nodes
+	InverseBindMatrices [][16]float32
+	Palette             [][16]float32
+}
+
+// decodeSkins lê todas as skins do documento.
+func decodeSkins(doc *gltf.Document) ([]*Skin, error) {
+	skins := make([]*Skin, 0, len(doc.Skins))
+	for i, s := range doc.Skins {
+		skin := &Skin{
+			Name:                s.Name,
+			Joints:              append([]int(nil), s.Joints...),
+			InverseBindMatrices: make([][16]float32, len(s.Joints)),
+			Palette:             make([][16]float32, len(s.Joints)),
+		}
+
+		for j, nodeIdx := range skin.Joints {
+			if nodeIdx < 0 || nodeIdx >= len(doc.Nodes) {
+				return nil, fmt.Errorf("skin %d: joint %d aponta para node %d fora do range", i, j, nodeIdx)
+			}
+			skin.InverseBindMatrices[j] = mat4fIdentity()
+		}
+
+		if s.InverseBindMatrices != nil {
+			if *s.InverseBindMatrices < 0 || *s.InverseBindMatrices >= len(doc.Accessors) {
+				return nil, fmt.Errorf("skin %d: accessor %d de inverse bind matrices fora do range", i, *s.InverseBindMatrices)
+			}
+			ibm, err := modeler.ReadInverseBindMatrices(doc, doc.Accessors[*s.InverseBindMatrices], nil)
+			if err != nil {
+				return nil, fmt.Errorf("skin %d: erro lendo inverse bind matrices: %w", i, err)
+			}
+			for j := 0; j < len(ibm) && j < len(skin.Joints); j++ {
+				// [4][4]float32 do modeler já está em column-major (coluna, linha)
+				for c := 0; c < 4; c++ {
+					for r := 0; r < 4; r++ {
+						skin.InverseBindMatrices[j][c*4+r] = ibm[j][c][r]
+					}
+				}
+			}
+		}
+
+		logger.Infof("skin %q: %d joints", skin.Name, len(skin.Joints))
+		skins = append(skins, skin)
+	}
+	return skins, nil
+}
+
+// updatePalette recalcula a matrix palette a partir dos World dos nós.
+func (s *Skin) updatePalette(nodes []*Node) {
+	for j, nodeIdx := range s.Joints {
+		s.Palette[j] = mat4fMul(nodes[nodeIdx].World, s.InverseBindMatrices[j])
+	}
+}
+
+// cloneSkins copia as skins, mantendo as inverse bind matrices compartilhadas
+// (são somente leitura) e alocando uma palette própria.
+func cloneSkins(skins []*Skin) []*Skin {
+	out := make([]*Skin, len(skins))
+	for i, s := range skins {
+		c := *s
+		c.Palette = append([][16]float32(nil), s.Palette...)
+		out[i] = &c
+	}
+	return out
+}
+
+// normalizeWeights garante que os pesos de cada vértice somem 1.
+func normalizeWeights(weights [][4]float32) {
+	for i, w := range weights {
+		sum := w[0] + w[1] + w[2] + w[3]
+		if sum > 0 && sum != 1 {
+			weights[i] = [4]float32{w[0] / sum, w[1] / sum, w[2] / sum, w[3] / sum}
+		}
+	}
+}
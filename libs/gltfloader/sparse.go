@@ -0,0 +1,114 @@
+package gltfloader
+
+import (
+	"fmt"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// resolveSparse aplica os accessors esparsos do documento. Cada accessor
+// esparso ganha um buffer denso próprio (a base do buffer view, ou zeros sem
+// buffer view, com os valores substituídos) e perde o Sparse, então o resto
+// do decode lê todos os accessors do mesmo jeito.
+//
+// O modeler da qmuntal/gltf também aplica sparse, mas reaproveita buffers de
+// um pool sem zerá-los e não confere os índices contra o count do accessor.
+func resolveSparse(doc *gltf.Document) error {
+	for i, acr := range doc.Accessors {
+		if acr.Sparse == nil {
+			continue
+		}
+		data, err := denseAccessor(doc, acr)
+		if err != nil {
+			return fmt.Errorf("accessor %d: %w", i, err)
+		}
+
+		doc.Buffers = append(doc.Buffers, &gltf.Buffer{ByteLength: len(data), Data: data})
+		doc.BufferViews = append(doc.BufferViews, &gltf.BufferView{
+			Buffer:     len(doc.Buffers) - 1,
+			ByteLength: len(data),
+		})
+		bv := len(doc.BufferViews) - 1
+		acr.BufferView = &bv
+		acr.ByteOffset = 0
+		acr.Sparse = nil
+	}
+	return nil
+}
+
+// denseAccessor monta os bytes do accessor esparso, com os elementos
+// contíguos (sem byteStride).
+func denseAccessor(doc *gltf.Document, acr *gltf.Accessor) ([]byte, error) {
+	size := gltf.SizeOfElement(acr.ComponentType, acr.Type)
+	if size == 0 || acr.Count < 0 {
+		return nil, fmt.Errorf("tipo de accessor inválido")
+	}
+	out := make([]byte, acr.Count*size)
+
+	if acr.BufferView != nil {
+		src, stride, err := accessorBytes(doc, *acr.BufferView, acr.ByteOffset, acr.Count, size)
+		if err != nil {
+			return nil, err
+		}
+		for e := 0; e < acr.Count; e++ {
+			copy(out[e*size:(e+1)*size], src[e*stride:])
+		}
+	}
+
+	sp := acr.Sparse
+	if sp.Count <= 0 || sp.Count > acr.Count {
+		return nil, fmt.Errorf("sparse.count %d inválido para %d elementos", sp.Count, acr.Count)
+	}
+
+	indices, err := modeler.ReadIndices(doc, &gltf.Accessor{
+		ComponentType: sp.Indices.ComponentType,
+		Type:          gltf.AccessorScalar,
+		Count:         sp.Count,
+		BufferView:    &sp.Indices.BufferView,
+		ByteOffset:    sp.Indices.ByteOffset,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler sparse.indices: %w", err)
+	}
+
+	values, _, err := accessorBytes(doc, sp.Values.BufferView, sp.Values.ByteOffset, sp.Count, size)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler sparse.values: %w", err)
+	}
+
+	for i, idx := range indices {
+		if int(idx) >= acr.Count {
+			return nil, fmt.Errorf("índice esparso %d fora do range (%d elementos)", idx, acr.Count)
+		}
+		copy(out[int(idx)*size:(int(idx)+1)*size], values[i*size:])
+	}
+	return out, nil
+}
+
+// accessorBytes retorna os bytes de count elementos de size bytes a partir
+// de offset no buffer view, e a distância entre elementos. Sparse values são
+// sempre contíguos; a base do accessor pode ter byteStride.
+func accessorBytes(doc *gltf.Document, view, offset, count, size int) ([]byte, int, error) {
+	if view < 0 || view >= len(doc.BufferViews) {
+		return nil, 0, fmt.Errorf("buffer view %d fora do range", view)
+	}
+	bv := doc.BufferViews[view]
+	buf, err := modeler.ReadBufferView(doc, bv)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stride := size
+	if bv.ByteStride != 0 {
+		stride = bv.ByteStride
+	}
+	need := offset
+	if count > 0 {
+		need += (count-1)*stride + size
+	}
+	if offset < 0 || need > len(buf) {
+		return nil, 0, fmt.Errorf("buffer view %d com %d bytes, esperado pelo menos %d", view, len(buf), need)
+	}
+	return buf[offset:], stride, nil
+}
@@ -0,0 +1,195 @@
+package gltfloader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// addSparseVec3 adiciona ao doc um accessor VEC3 float com count elementos.
+// base vira o buffer view do accessor (nil para um accessor sem buffer
+// view) e indices/values o bloco sparse.
+func addSparseVec3(doc *gltf.Document, base [][3]float32, count int, indices []uint16, values [][3]float32) int {
+	acr := &gltf.Accessor{
+		ComponentType: gltf.ComponentFloat,
+		Type:          gltf.AccessorVec3,
+		Count:         count,
+		Sparse: &gltf.Sparse{
+			Count: len(indices),
+			Indices: gltf.SparseIndices{
+				BufferView:    modeler.WriteBufferView(doc, gltf.TargetNone, indices),
+				ComponentType: gltf.ComponentUshort,
+			},
+			Values: gltf.SparseValues{
+				BufferView: modeler.WriteBufferView(doc, gltf.TargetNone, values),
+			},
+		},
+	}
+	if base != nil {
+		bv := modeler.WriteBufferView(doc, gltf.TargetArrayBuffer, base)
+		acr.BufferView = &bv
+	}
+	doc.Accessors = append(doc.Accessors, acr)
+	return len(doc.Accessors) - 1
+}
+
+func readResolvedVec3(t *testing.T, doc *gltf.Document, idx int) [][3]float32 {
+	t.Helper()
+	if err := resolveSparse(doc); err != nil {
+		t.Fatalf("resolveSparse: %v", err)
+	}
+	if doc.Accessors[idx].Sparse != nil {
+		t.Fatalf("accessor %d continua esparso", idx)
+	}
+	got, err := readVec[[3]float32](doc, doc.Accessors[idx])
+	if err != nil {
+		t.Fatalf("readVec: %v", err)
+	}
+	return got
+}
+
+func equalVec3(a, b [][3]float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSparseOverridesBufferView(t *testing.T) {
+	doc := gltf.NewDocument()
+	base := [][3]float32{{0, 0, 0}, {1, 1, 1}, {2, 2, 2}, {3, 3, 3}}
+	idx := addSparseVec3(doc, base, len(base), []uint16{1, 3}, [][3]float32{{10, 11, 12}, {30, 31, 32}})
+
+	got := readResolvedVec3(t, doc, idx)
+	want := [][3]float32{{0, 0, 0}, {10, 11, 12}, {2, 2, 2}, {30, 31, 32}}
+	if !equalVec3(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSparseWithoutBufferViewStartsFromZero(t *testing.T) {
+	doc := gltf.NewDocument()
+	idx := addSparseVec3(doc, nil, 4, []uint16{2}, [][3]float32{{5, 6, 7}})
+
+	got := readResolvedVec3(t, doc, idx)
+	want := [][3]float32{{}, {}, {5, 6, 7}, {}}
+	if !equalVec3(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSparseInterleavedBase(t *testing.T) {
+	doc := gltf.NewDocument()
+	// Posição e normal intercaladas no mesmo buffer view (stride 24)
+	bv, err := modeler.WriteBufferViewInterleaved(doc,
+		[][3]float32{{1, 0, 0}, {2, 0, 0}, {3, 0, 0}},
+		[][3]float32{{0, 1, 0}, {0, 1, 0}, {0, 1, 0}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := addSparseVec3(doc, nil, 3, []uint16{0}, [][3]float32{{9, 9, 9}})
+	doc.Accessors[idx].BufferView = &bv
+
+	got := readResolvedVec3(t, doc, idx)
+	want := [][3]float32{{9, 9, 9}, {2, 0, 0}, {3, 0, 0}}
+	if !equalVec3(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSparseLeavesSharedBufferViewIntact(t *testing.T) {
+	doc := gltf.NewDocument()
+	base := [][3]float32{{1, 2, 3}, {4, 5, 6}}
+	sparse := addSparseVec3(doc, base, len(base), []uint16{0}, [][3]float32{{7, 8, 9}})
+	plain := &gltf.Accessor{
+		ComponentType: gltf.ComponentFloat,
+		Type:          gltf.AccessorVec3,
+		Count:         len(base),
+		BufferView:    doc.Accessors[sparse].BufferView,
+	}
+	doc.Accessors = append(doc.Accessors, plain)
+
+	readResolvedVec3(t, doc, sparse)
+	got, err := readVec[[3]float32](doc, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalVec3(got, base) {
+		t.Errorf("accessor sem sparse mudou: got %v, want %v", got, base)
+	}
+}
+
+func TestSparseRejectsBadIndices(t *testing.T) {
+	tests := []struct {
+		name    string
+		count   int
+		indices []uint16
+		values  [][3]float32
+		want    string
+	}{
+		{"índice fora do range", 2, []uint16{2}, [][3]float32{{1, 1, 1}}, "fora do range"},
+		{"mais valores que elementos", 1, []uint16{0, 0}, [][3]float32{{1, 1, 1}, {2, 2, 2}}, "sparse.count"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := gltf.NewDocument()
+			addSparseVec3(doc, nil, tt.count, tt.indices, tt.values)
+			err := resolveSparse(doc)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want contendo %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSparseMissingValues(t *testing.T) {
+	doc := gltf.NewDocument()
+	idx := addSparseVec3(doc, nil, 4, []uint16{0, 1}, [][3]float32{{1, 1, 1}})
+	doc.Accessors[idx].Sparse.Count = 2
+
+	if err := resolveSparse(doc); err == nil || !strings.Contains(err.Error(), "sparse.values") {
+		t.Errorf("err = %v, want erro em sparse.values", err)
+	}
+}
+
+func TestDecodeSparseMorphTarget(t *testing.T) {
+	doc := gltf.NewDocument()
+	positions := [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+	normals := [][3]float32{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}}
+	target := addSparseVec3(doc, nil, len(positions), []uint16{2}, [][3]float32{{0, 0.5, 0}})
+
+	mesh := 0
+	doc.Meshes = []*gltf.Mesh{{
+		Name: "tri",
+		Primitives: []*gltf.Primitive{{
+			Attributes: gltf.PrimitiveAttributes{
+				gltf.POSITION: modeler.WritePosition(doc, positions),
+				gltf.NORMAL:   modeler.WriteNormal(doc, normals),
+			},
+			Targets: []gltf.PrimitiveAttributes{{gltf.POSITION: target}},
+		}},
+	}}
+	doc.Nodes = []*gltf.Node{{Mesh: &mesh, Matrix: gltf.DefaultMatrix}}
+	doc.Scenes[0].Nodes = []int{0}
+
+	data, err := Decode(doc, nil, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(data.Meshes) != 1 || len(data.Meshes[0].Targets) != 1 {
+		t.Fatalf("esperado 1 mesh com 1 morph target")
+	}
+	got := data.Meshes[0].Targets[0].Positions
+	want := [][3]float32{{}, {}, {0, 0.5, 0}}
+	if !equalVec3(got, want) {
+		t.Errorf("deltas do target: got %v, want %v", got, want)
+	}
+}
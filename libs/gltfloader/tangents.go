@@ -0,0 +1,439 @@
+package gltfloader
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// Flags de triângulo do mikktspace.
+const (
+	// mikkGroupWithAny marca triângulos com UV degenerada: não abrem grupos
+	// nem contribuem para a média, só herdam o espaço dos vizinhos.
+	mikkGroupWithAny = 1 << iota
+	// mikkOrientPreserving marca triângulos com área positiva em UV, ou seja,
+	// handedness +1.
+	mikkOrientPreserving
+)
+
+// mikkThresCos é o cosseno do limiar angular padrão do mikktspace (180°):
+// cantos de um grupo só se separam em subgrupos com derivadas opostas.
+const mikkThresCos = -1
+
+// mikkEpsilon é o FLT_MIN usado pelo NotZero da implementação de referência.
+const mikkEpsilon = 0x1p-126
+
+type mikkTriangle struct {
+	neighbors [3]int     // triângulo do outro lado da aresta i -> i+1, ou -1
+	group     [3]int     // grupo de cada canto, ou -1
+	os, ot    [3]float32 // derivadas da posição em s e t, normalizadas
+	corner    int        // primeiro canto do triângulo na lista original
+	flags     int
+}
+
+// mikkGroup junta os cantos ligados por arestas em volta do mesmo vértice
+// soldado e com a mesma handedness.
+type mikkGroup struct {
+	vertex int // canto representante (índice soldado)
+	orient bool
+	faces  []int
+}
+
+type mikkSpace struct {
+	os, ot [3]float32
+	orient bool
+}
+
+// generateMikkTSpaceTangents gera tangentes compatíveis com o MikkTSpace
+// (o mesmo algoritmo do Blender, do Substance e dos bakers em geral) para
+// uma primitiva de triângulos com normais, usando o conjunto de UVs dado.
+// É um port do mikktspace.c de referência, com o limiar angular padrão:
+//   - vértices com posição, normal e UV iguais são soldados;
+//   - os cantos de cada vértice soldado são agrupados por conectividade e
+//     handedness, e cada grupo ganha a média das derivadas dos seus
+//     triângulos, ponderada pelo ângulo do canto;
+//   - triângulos degenerados copiam o espaço de um canto bom do mesmo vértice.
+//
+// O eixo v do glTF aponta para baixo e o do mikktspace para cima, então v é
+// invertido antes do cálculo: um quad comum sai com T = +U e w = +1, e a
+// bitangente é w * cross(N, T). Um vértice cujos cantos terminam com
+// tangentes diferentes é duplicado, e a primitiva vira uma lista de
+// triângulos indexada.
+func generateMikkTSpaceTangents(md *MeshData, uvs [][2]float32) {
+	vertCount := len(md.Positions)
+	if len(uvs) < vertCount || len(md.Normals) < vertCount {
+		return
+	}
+	tris := triangleList(md.Mode, md.Indices, vertCount)
+	tris = tris[:len(tris)-len(tris)%3]
+	for _, v := range tris {
+		if int(v) >= vertCount {
+			return
+		}
+	}
+
+	pos := func(c int) [3]float32 { return md.Positions[tris[c]] }
+	normal := func(c int) [3]float32 { return md.Normals[tris[c]] }
+	tex := func(c int) [2]float32 {
+		uv := uvs[tris[c]]
+		return [2]float32{uv[0], 1 - uv[1]}
+	}
+
+	// Solda os cantos com posição, normal e UV iguais: weld[c] é o primeiro
+	// canto com os mesmos atributos.
+	type weldKey struct {
+		p, n [3]float32
+		uv   [2]float32
+	}
+	weld := make([]int, len(tris))
+	first := make(map[weldKey]int)
+	for c := range tris {
+		k := weldKey{pos(c), normal(c), tex(c)}
+		if f, ok := first[k]; ok {
+			weld[c] = f
+		} else {
+			first[k] = c
+			weld[c] = c
+		}
+	}
+
+	// Triângulos com posições repetidas ficam de fora dos grupos e recebem
+	// o espaço de outro canto no fim.
+	var info []mikkTriangle
+	var degenerate []int
+	for c := 0; c < len(tris); c += 3 {
+		p0, p1, p2 := pos(c), pos(c+1), pos(c+2)
+		if p0 == p1 || p0 == p2 || p1 == p2 {
+			degenerate = append(degenerate, c)
+			continue
+		}
+		info = append(info, mikkTriangle{
+			neighbors: [3]int{-1, -1, -1},
+			group:     [3]int{-1, -1, -1},
+			corner:    c,
+		})
+	}
+	vert := func(f, i int) int { return weld[info[f].corner+i] }
+	cornerOf := func(f, vertex int) int {
+		for i := 0; i < 3; i++ {
+			if vert(f, i) == vertex {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for f := range info {
+		initMikkTriangle(&info[f], pos, tex)
+	}
+	buildMikkNeighbors(info, vert)
+
+	// Agrupa os cantos de cada vértice andando pelas arestas compartilhadas.
+	var groups []*mikkGroup
+	var assign func(f, gi int)
+	assign = func(f, gi int) {
+		g := groups[gi]
+		t := &info[f]
+		i := cornerOf(f, g.vertex)
+		if i < 0 || t.group[i] != -1 {
+			return
+		}
+		// O primeiro grupo a alcançar um triângulo de UV degenerada decide
+		// a handedness dele.
+		if t.flags&mikkGroupWithAny != 0 && t.group == [3]int{-1, -1, -1} {
+			t.flags &^= mikkOrientPreserving
+			if g.orient {
+				t.flags |= mikkOrientPreserving
+			}
+		}
+		if (t.flags&mikkOrientPreserving != 0) != g.orient {
+			return
+		}
+		g.faces = append(g.faces, f)
+		t.group[i] = gi
+		if n := t.neighbors[i]; n >= 0 {
+			assign(n, gi)
+		}
+		if n := t.neighbors[(i+2)%3]; n >= 0 {
+			assign(n, gi)
+		}
+	}
+	for f := range info {
+		for i := 0; i < 3; i++ {
+			t := &info[f]
+			if t.flags&mikkGroupWithAny != 0 || t.group[i] != -1 {
+				continue
+			}
+			gi := len(groups)
+			groups = append(groups, &mikkGroup{
+				vertex: vert(f, i),
+				orient: t.flags&mikkOrientPreserving != 0,
+				faces:  []int{f},
+			})
+			t.group[i] = gi
+			if n := t.neighbors[i]; n >= 0 {
+				assign(n, gi)
+			}
+			if n := t.neighbors[(i+2)%3]; n >= 0 {
+				assign(n, gi)
+			}
+		}
+	}
+
+	// Espaço de cada canto; cantos sem grupo ficam com o padrão da referência.
+	spaces := make([]mikkSpace, len(tris))
+	for c := range spaces {
+		spaces[c].os = [3]float32{1, 0, 0}
+		spaces[c].ot = [3]float32{0, 1, 0}
+	}
+
+	for gi, g := range groups {
+		n := normal(g.vertex)
+		var subgroups [][]int
+		var subSpaces []mikkSpace
+		for _, f := range g.faces {
+			i := slices.Index(info[f].group[:], gi)
+			os := mikkProject(n, info[f].os)
+			ot := mikkProject(n, info[f].ot)
+
+			var members []int
+			for _, t := range g.faces {
+				withAny := (info[f].flags|info[t].flags)&mikkGroupWithAny != 0
+				os2 := mikkProject(n, info[t].os)
+				ot2 := mikkProject(n, info[t].ot)
+				if withAny || f == t || (dot3(os, os2) > mikkThresCos && dot3(ot, ot2) > mikkThresCos) {
+					members = append(members, t)
+				}
+			}
+			sort.Ints(members)
+
+			l := slices.IndexFunc(subgroups, func(s []int) bool { return slices.Equal(s, members) })
+			if l < 0 {
+				l = len(subgroups)
+				subgroups = append(subgroups, members)
+				subSpaces = append(subSpaces, evalMikkSpace(info, members, g.vertex, n, cornerOf, pos))
+			}
+			sp := subSpaces[l]
+			sp.orient = g.orient
+			spaces[info[f].corner+i] = sp
+		}
+	}
+
+	// Cantos de triângulos degenerados copiam o espaço do primeiro canto bom
+	// com o mesmo vértice soldado.
+	if len(degenerate) > 0 {
+		good := make(map[int]int)
+		for f := range info {
+			for i := 0; i < 3; i++ {
+				if _, ok := good[vert(f, i)]; !ok {
+					good[vert(f, i)] = info[f].corner + i
+				}
+			}
+		}
+		for _, c := range degenerate {
+			for i := 0; i < 3; i++ {
+				if src, ok := good[weld[c+i]]; ok {
+					spaces[c+i] = spaces[src]
+				}
+			}
+		}
+	}
+
+	// Um vértice por tangente: cantos do mesmo vértice com espaços
+	// diferentes ganham cópias.
+	type vertexTangent struct {
+		v   uint32
+		tan [4]float32
+	}
+	remap := make([]uint32, vertCount)
+	for i := range remap {
+		remap[i] = uint32(i)
+	}
+	tangents := make([][4]float32, vertCount)
+	assigned := make([]bool, vertCount)
+	copies := make(map[vertexTangent]uint32)
+	newTris := make([]uint32, len(tris))
+	split := false
+	for c, v := range tris {
+		sp := spaces[c]
+		w := float32(-1)
+		if sp.orient {
+			w = 1
+		}
+		tan := [4]float32{sp.os[0], sp.os[1], sp.os[2], w}
+
+		switch {
+		case !assigned[v]:
+			tangents[v] = tan
+			assigned[v] = true
+			newTris[c] = v
+		case tangents[v] == tan:
+			newTris[c] = v
+		default:
+			k := vertexTangent{v, tan}
+			idx, ok := copies[k]
+			if !ok {
+				idx = uint32(len(remap))
+				remap = append(remap, v)
+				tangents = append(tangents, tan)
+				copies[k] = idx
+			}
+			newTris[c] = idx
+			split = true
+		}
+	}
+
+	if split {
+		remapVertices(md, remap)
+		md.Indices = newTris
+		md.Mode = ModeTriangles
+	}
+	md.Tangents = tangents
+}
+
+// initMikkTriangle calcula as derivadas da posição em relação a s e t e a
+// handedness do triângulo (InitTriInfo na referência).
+func initMikkTriangle(t *mikkTriangle, pos func(int) [3]float32, tex func(int) [2]float32) {
+	t.flags = mikkGroupWithAny
+
+	c := t.corner
+	v1, v2, v3 := pos(c), pos(c+1), pos(c+2)
+	t1, t2, t3 := tex(c), tex(c+1), tex(c+2)
+
+	t21x, t21y := t2[0]-t1[0], t2[1]-t1[1]
+	t31x, t31y := t3[0]-t1[0], t3[1]-t1[1]
+	d1 := sub3(v2, v1)
+	d2 := sub3(v3, v1)
+
+	area := t21x*t31y - t21y*t31x
+	os := sub3(scale3(d1, t31y), scale3(d2, t21y))
+	ot := add3(scale3(d1, -t31x), scale3(d2, t21x))
+
+	if area > 0 {
+		t.flags |= mikkOrientPreserving
+	}
+	if !mikkNotZero(area) {
+		return
+	}
+
+	absArea := float32(math.Abs(float64(area)))
+	lenOs := length3(os)
+	lenOt := length3(ot)
+	s := float32(1)
+	if area < 0 {
+		s = -1
+	}
+	if mikkNotZero(lenOs) {
+		t.os = scale3(os, s/lenOs)
+	}
+	if mikkNotZero(lenOt) {
+		t.ot = scale3(ot, s/lenOt)
+	}
+	if mikkNotZero(lenOs/absArea) && mikkNotZero(lenOt/absArea) {
+		t.flags &^= mikkGroupWithAny
+	}
+}
+
+// buildMikkNeighbors liga cada aresta ao triângulo do outro lado. Uma aresta
+// só casa com outra no sentido oposto (mesma orientação de winding), e cada
+// aresta casa com no máximo uma, na ordem dos triângulos.
+func buildMikkNeighbors(info []mikkTriangle, vert func(f, i int) int) {
+	type edgeKey struct{ a, b int }
+	type edgeRef struct{ f, e int }
+	edges := make(map[edgeKey][]edgeRef)
+	for f := range info {
+		for e := 0; e < 3; e++ {
+			a, b := vert(f, e), vert(f, (e+1)%3)
+			if a > b {
+				a, b = b, a
+			}
+			edges[edgeKey{a, b}] = append(edges[edgeKey{a, b}], edgeRef{f, e})
+		}
+	}
+
+	for _, list := range edges {
+		for i, ea := range list {
+			if info[ea.f].neighbors[ea.e] != -1 {
+				continue
+			}
+			a0, a1 := vert(ea.f, ea.e), vert(ea.f, (ea.e+1)%3)
+			for _, eb := range list[i+1:] {
+				if info[eb.f].neighbors[eb.e] != -1 {
+					continue
+				}
+				if vert(eb.f, eb.e) == a1 && vert(eb.f, (eb.e+1)%3) == a0 {
+					info[ea.f].neighbors[ea.e] = eb.f
+					info[eb.f].neighbors[eb.e] = ea.f
+					break
+				}
+			}
+		}
+	}
+}
+
+// evalMikkSpace é a média das derivadas dos triângulos de um subgrupo no
+// canto do vértice, ponderada pelo ângulo do canto (EvalTspace na
+// referência). Triângulos de UV degenerada não contribuem.
+func evalMikkSpace(info []mikkTriangle, members []int, vertex int, n [3]float32,
+	cornerOf func(f, vertex int) int, pos func(int) [3]float32) mikkSpace {
+	var res mikkSpace
+	for _, f := range members {
+		t := &info[f]
+		if t.flags&mikkGroupWithAny != 0 {
+			continue
+		}
+		i := cornerOf(f, vertex)
+		os := mikkProject(n, t.os)
+		ot := mikkProject(n, t.ot)
+
+		p0 := pos(t.corner + (i+2)%3)
+		p1 := pos(t.corner + i)
+		p2 := pos(t.corner + (i+1)%3)
+		v1 := mikkProject(n, sub3(p0, p1))
+		v2 := mikkProject(n, sub3(p2, p1))
+
+		c := dot3(v1, v2)
+		if c > 1 {
+			c = 1
+		} else if c < -1 {
+			c = -1
+		}
+		angle := float32(math.Acos(float64(c)))
+
+		res.os = add3(res.os, scale3(os, angle))
+		res.ot = add3(res.ot, scale3(ot, angle))
+	}
+	res.os = mikkNormalize(res.os)
+	res.ot = mikkNormalize(res.ot)
+	return res
+}
+
+// mikkProject remove de v a componente na direção da normal n e normaliza.
+func mikkProject(n, v [3]float32) [3]float32 {
+	return mikkNormalize(sub3(v, scale3(n, dot3(n, v))))
+}
+
+// mikkNormalize normaliza v, a menos que todas as componentes sejam zero
+// (VNotZero na referência).
+func mikkNormalize(v [3]float32) [3]float32 {
+	if !mikkNotZero(v[0]) && !mikkNotZero(v[1]) && !mikkNotZero(v[2]) {
+		return v
+	}
+	return normalize3(v)
+}
+
+func mikkNotZero(x float32) bool {
+	return math.Abs(float64(x)) > mikkEpsilon
+}
+
+func add3(a, b [3]float32) [3]float32 {
+	return [3]float32{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+func scale3(v [3]float32, s float32) [3]float32 {
+	return [3]float32{v[0] * s, v[1] * s, v[2] * s}
+}
+
+func length3(v [3]float32) float32 {
+	return float32(math.Sqrt(float64(dot3(v, v))))
+}
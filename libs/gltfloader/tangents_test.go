@@ -0,0 +1,96 @@
+package gltfloader
+
+import (
+	"math"
+	"testing"
+)
+
+// quadMesh é um quad no plano XY com normal +Z. us são as coordenadas u dos
+// vértices; v segue a convenção do glTF (0 em cima, em y = 1).
+func quadMesh(positions [][3]float32, us []float32, indices []uint32) *MeshData {
+	md := &MeshData{Positions: positions, Indices: indices, Mode: ModeTriangles}
+	for i, p := range positions {
+		md.Normals = append(md.Normals, [3]float32{0, 0, 1})
+		md.UVs = append(md.UVs, [2]float32{us[i], 1 - p[1]})
+	}
+	return md
+}
+
+func nearTangent(a, b [4]float32) bool {
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > 1e-5 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMikkTSpaceQuad(t *testing.T) {
+	md := quadMesh(
+		[][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0}},
+		[]float32{0, 1, 0, 1},
+		[]uint32{0, 1, 2, 2, 1, 3},
+	)
+	generateMikkTSpaceTangents(md, md.UVs)
+
+	if len(md.Positions) != 4 {
+		t.Fatalf("quad sem espelhamento não deveria duplicar vértices: %d vértices", len(md.Positions))
+	}
+	for i, tan := range md.Tangents {
+		if !nearTangent(tan, [4]float32{1, 0, 0, 1}) {
+			t.Errorf("vértice %d: tangente %v, want [1 0 0 1]", i, tan)
+		}
+	}
+}
+
+func TestMikkTSpaceMirroredSeam(t *testing.T) {
+	// Dois quads lado a lado; o da direita espelha u, com a costura em x = 1
+	md := quadMesh(
+		[][3]float32{{0, 0, 0}, {1, 0, 0}, {2, 0, 0}, {0, 1, 0}, {1, 1, 0}, {2, 1, 0}},
+		[]float32{0, 1, 0, 0, 1, 0},
+		[]uint32{0, 1, 3, 3, 1, 4, 1, 2, 4, 4, 2, 5},
+	)
+	generateMikkTSpaceTangents(md, md.UVs)
+
+	if len(md.Positions) != 8 {
+		t.Fatalf("os 2 vértices da costura deveriam ser duplicados: %d vértices", len(md.Positions))
+	}
+	if len(md.Tangents) != len(md.Positions) {
+		t.Fatalf("%d tangentes para %d vértices", len(md.Tangents), len(md.Positions))
+	}
+	for tri := 0; tri < len(md.Indices); tri += 3 {
+		want := [4]float32{1, 0, 0, 1}
+		if tri >= 6 {
+			want = [4]float32{-1, 0, 0, -1}
+		}
+		for _, v := range md.Indices[tri : tri+3] {
+			if !nearTangent(md.Tangents[v], want) {
+				t.Errorf("triângulo %d, vértice %d: tangente %v, want %v", tri/3, v, md.Tangents[v], want)
+			}
+		}
+	}
+}
+
+func TestNormalMapUVs(t *testing.T) {
+	uv0 := [][2]float32{{0, 0}}
+	uv1 := [][2]float32{{1, 1}}
+	tests := []struct {
+		name string
+		mat  *Material
+		want [][2]float32
+	}{
+		{"sem normal map", &Material{}, nil},
+		{"texCoord 0", &Material{NormalTexture: &TextureRef{}}, uv0},
+		{"texCoord 1", &Material{NormalTexture: &TextureRef{TexCoord: 1}}, uv1},
+		{"clearcoat", &Material{Clearcoat: &Clearcoat{NormalTexture: &TextureRef{TexCoord: 1}}}, uv1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := &MeshData{UVs: uv0, UVs1: uv1, Material: tt.mat}
+			got := normalMapUVs(md)
+			if len(got) != len(tt.want) || (got != nil && got[0] != tt.want[0]) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package gltfloader
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"image"
+	"sync"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/joaqu1m/gogl-playground/libs/gltrack"
+	"github.com/joaqu1m/gogl-playground/libs/logger"
+)
+
+// textureCacheKey identifica uma textura pelo conteúdo da imagem e pelo
+// sampler: a mesma imagem com wrap/filtros diferentes é outro texture object.
+type textureCacheKey struct {
+	hash    ImageHash
+	sampler Sampler
+}
+
+// ImageHash é o SHA-256 do conteúdo de uma imagem decodificada (ver
+// hashImage). É calculado nos workers do decode, fora da thread do GL.
+type ImageHash [sha256.Size]byte
+
+type cachedTexture struct {
+	key  textureCacheKey
+	id   uint32
+	refs int
+}
+
+// textureCache é o cache de texturas do processo, compartilhado por todos os
+// modelos. Cada acquireTexture precisa de um releaseTexture correspondente.
+var textureCache = struct {
+	sync.Mutex
+	byKey map[textureCacheKey]*cachedTexture
+	byID  map[uint32]*cachedTexture
+}{
+	byKey: make(map[textureCacheKey]*cachedTexture),
+	byID:  make(map[uint32]*cachedTexture),
+}
+
+// acquireTexture retorna o texture object da imagem com o sampler dado,
+// enviando-a ao OpenGL só se ainda não estiver no cache. hash é o
+// hashImage de img, já calculado no decode. Precisa do contexto GL.
+func acquireTexture(img *image.RGBA, hash ImageHash, sampler Sampler, label string) uint32 {
+	key := textureCacheKey{hash: hash, sampler: sampler}
+
+	textureCache.Lock()
+	defer textureCache.Unlock()
+
+	if t, ok := textureCache.byKey[key]; ok {
+		t.refs++
+		logger.Debugf("gltfloader: textura %d reaproveitada do cache (%s, %d refs)", t.id, label, t.refs)
+		return t.id
+	}
+
+	id := uploadImageToGL(img, sampler)
+	gltrack.Track(gltrack.Texture, id, label)
+
+	t := &cachedTexture{key: key, id: id, refs: 1}
+	textureCache.byKey[key] = t
+	textureCache.byID[id] = t
+	return id
+}
+
+// releaseTexture solta uma referência à textura e a deleta quando for a
+// última. Precisa do contexto GL.
+func releaseTexture(id uint32) {
+	textureCache.Lock()
+	defer textureCache.Unlock()
+
+	t, ok := textureCache.byID[id]
+	if !ok {
+		return
+	}
+	t.refs--
+	if t.refs > 0 {
+		return
+	}
+
+	gl.DeleteTextures(1, &t.id)
+	gltrack.Untrack(gltrack.Texture, t.id)
+	delete(textureCache.byKey, t.key)
+	delete(textureCache.byID, id)
+}
+
+// CachedTextures retorna quantas texturas estão no cache compartilhado.
+func CachedTextures() int {
+	textureCache.Lock()
+	defer textureCache.Unlock()
+	return len(textureCache.byID)
+}
+
+// hashImage calcula o hash do conteúdo de uma imagem RGBA: dimensões e
+// pixels, ignorando o padding de stride.
+func hashImage(img *image.RGBA) ImageHash {
+	h := sha256.New()
+	b := img.Bounds()
+
+	var dims [8]byte
+	binary.LittleEndian.PutUint32(dims[0:], uint32(b.Dx()))
+	binary.LittleEndian.PutUint32(dims[4:], uint32(b.Dy()))
+	h.Write(dims[:])
+
+	rowLen := b.Dx() * 4
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		off := img.PixOffset(b.Min.X, y)
+		h.Write(img.Pix[off : off+rowLen])
+	}
+
+	var sum ImageHash
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
@@ -0,0 +1,257 @@
+package gltfloader
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"runtime"
+	"sync"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// WrapMode é o modo de repetição de UV de um sampler.
+type WrapMode int
+
+const (
+	WrapRepeat WrapMode = iota
+	WrapClampToEdge
+	WrapMirroredRepeat
+)
+
+// Filter é um filtro de minificação/magnificação de um sampler.
+type Filter int
+
+const (
+	FilterLinear Filter = iota
+	FilterNearest
+	FilterNearestMipmapNearest
+	FilterLinearMipmapNearest
+	FilterNearestMipmapLinear
+	FilterLinearMipmapLinear
+)
+
+// UsesMipmaps informa se o filtro amostra mipmaps.
+func (f Filter) UsesMipmaps() bool {
+	return f >= FilterNearestMipmapNearest
+}
+
+// Sampler são os parâmetros de amostragem de uma textura glTF. É comparável
+// e pode ser usado como chave de cache.
+type Sampler struct {
+	MagFilter Filter
+	MinFilter Filter
+	WrapS     WrapMode
+	WrapT     WrapMode
+}
+
+// DefaultSampler é usado quando a textura não referencia sampler ou deixa
+// os filtros indefinidos: trilinear com REPEAT.
+func DefaultSampler() Sampler {
+	return Sampler{
+		MagFilter: FilterLinear,
+		MinFilter: FilterLinearMipmapLinear,
+		WrapS:     WrapRepeat,
+		WrapT:     WrapRepeat,
+	}
+}
+
+// Texture liga uma imagem (índice em ModelData.Images) a um sampler. Hash é
+// o conteúdo da imagem, usado como chave do cache de texturas.
+type Texture struct {
+	Image   int
+	Sampler Sampler
+	Hash    ImageHash
+}
+
+// decodeTextures decodifica as imagens usadas por texturas e resolve o
+// sampler de cada textura. Retorna imgIdx -> RGBA e texIdx -> Texture.
+// Imagens externas são lidas de fsys; se faltarem, o decode falha. A
+// decodificação PNG/JPEG roda em paralelo, uma goroutine por CPU. Imagens que
+// não decodificam e texturas sem imagem viram warnings em r.
+func decodeTextures(doc *gltf.Document, fsys fs.FS, r *Report) (map[int]*image.RGBA, map[int]*Texture, error) {
+	textures := make(map[int]*Texture)
+	encoded := make(map[int][]byte)
+
+	for i, tex := range doc.Textures {
+		if tex.Source == nil {
+			continue
+		}
+		imgIdx := *tex.Source
+		if imgIdx < 0 || imgIdx >= len(doc.Images) {
+			continue
+		}
+
+		if _, ok := encoded[imgIdx]; !ok {
+			img := doc.Images[imgIdx]
+			imgBytes, err := readImageBytes(doc, img, fsys)
+			if err != nil {
+				return nil, nil, fmt.Errorf("imagem %d (%q): %w", imgIdx, img.URI, err)
+			}
+			encoded[imgIdx] = imgBytes
+		}
+
+		sampler := DefaultSampler()
+		if tex.Sampler != nil && *tex.Sampler >= 0 && *tex.Sampler < len(doc.Samplers) {
+			sampler = convertSampler(doc.Samplers[*tex.Sampler])
+		}
+
+		textures[i] = &Texture{Image: imgIdx, Sampler: sampler}
+	}
+
+	images, hashes, failed := decodeImages(encoded)
+	for imgIdx, err := range failed {
+		r.add(SeverityWarning, fmt.Sprintf("/images/%d", imgIdx), "imagem ignorada: %v", err)
+	}
+	for texIdx, tex := range textures {
+		if _, ok := images[tex.Image]; !ok {
+			r.add(SeverityWarning, fmt.Sprintf("/textures/%d", texIdx), "imagem %d indisponível, desenhada sem mapa", tex.Image)
+			continue
+		}
+		tex.Hash = hashes[tex.Image]
+	}
+
+	return images, textures, nil
+}
+
+// decodeImages decodifica as imagens em paralelo e calcula o hash de cada
+// uma nos mesmos workers, para que o upload só consulte o cache. Imagens que
+// falham na decodificação ficam de fora do resultado, com o erro em failed,
+// e as texturas delas são desenhadas sem mapa.
+func decodeImages(encoded map[int][]byte) (images map[int]*image.RGBA, hashes map[int]ImageHash, failed map[int]error) {
+	type result struct {
+		idx  int
+		rgba *image.RGBA
+		hash ImageHash
+		err  error
+	}
+
+	jobs := make(chan int)
+	results := make(chan result)
+	workers := min(runtime.NumCPU(), len(encoded))
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				rgba, err := decodeImage(encoded[idx])
+				var hash ImageHash
+				if err == nil {
+					hash = hashImage(rgba)
+				}
+				results <- result{idx, rgba, hash, err}
+			}
+		}()
+	}
+	go func() {
+		for idx := range encoded {
+			jobs <- idx
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	images = make(map[int]*image.RGBA, len(encoded))
+	hashes = make(map[int]ImageHash, len(encoded))
+	failed = make(map[int]error)
+	for r := range results {
+		if r.err != nil {
+			failed[r.idx] = r.err
+			continue
+		}
+		images[r.idx] = r.rgba
+		hashes[r.idx] = r.hash
+	}
+	return images, hashes, failed
+}
+
+// readImageBytes retorna os bytes codificados (PNG/JPEG) de uma imagem glTF,
+// venha ela de um buffer view, de um data URI ou de um arquivo externo.
+func readImageBytes(doc *gltf.Document, img *gltf.Image, fsys fs.FS) ([]byte, error) {
+	if img.BufferView != nil {
+		// Imagem embedded no buffer
+		if *img.BufferView < 0 || *img.BufferView >= len(doc.BufferViews) {
+			return nil, fmt.Errorf("buffer view %d fora do range", *img.BufferView)
+		}
+		return modeler.ReadBufferView(doc, doc.BufferViews[*img.BufferView])
+	}
+
+	if img.IsEmbeddedResource() {
+		// Imagem embedded como data URI
+		return img.MarshalData()
+	}
+
+	// Imagem externa: o URI já vem sem escapes do decoder do qmuntal/gltf
+	if img.URI == "" {
+		return nil, fmt.Errorf("imagem sem bufferView nem uri")
+	}
+	if err := relativeURI(img.URI); err != nil {
+		return nil, err
+	}
+	if fsys == nil {
+		return nil, fmt.Errorf("imagem externa sem sistema de arquivos para resolvê-la")
+	}
+	return fs.ReadFile(fsys, img.URI)
+}
+
+// convertSampler traduz um sampler glTF, aplicando os defaults para
+// filtros indefinidos.
+func convertSampler(s *gltf.Sampler) Sampler {
+	out := DefaultSampler()
+
+	switch s.MagFilter {
+	case gltf.MagNearest:
+		out.MagFilter = FilterNearest
+	case gltf.MagLinear:
+		out.MagFilter = FilterLinear
+	}
+
+	switch s.MinFilter {
+	case gltf.MinNearest:
+		out.MinFilter = FilterNearest
+	case gltf.MinLinear:
+		out.MinFilter = FilterLinear
+	case gltf.MinNearestMipMapNearest:
+		out.MinFilter = FilterNearestMipmapNearest
+	case gltf.MinLinearMipMapNearest:
+		out.MinFilter = FilterLinearMipmapNearest
+	case gltf.MinNearestMipMapLinear:
+		out.MinFilter = FilterNearestMipmapLinear
+	case gltf.MinLinearMipMapLinear:
+		out.MinFilter = FilterLinearMipmapLinear
+	}
+
+	out.WrapS = convertWrap(s.WrapS)
+	out.WrapT = convertWrap(s.WrapT)
+	return out
+}
+
+func convertWrap(w gltf.WrappingMode) WrapMode {
+	switch w {
+	case gltf.WrapClampToEdge:
+		return WrapClampToEdge
+	case gltf.WrapMirroredRepeat:
+		return WrapMirroredRepeat
+	}
+	return WrapRepeat
+}
+
+// decodeImage decodifica bytes PNG/JPEG para RGBA 8 bits.
+func decodeImage(data []byte) (*image.RGBA, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
+	return rgba, nil
+}
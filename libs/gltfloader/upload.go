@@ -0,0 +1,290 @@
+package gltfloader
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/joaqu1m/gogl-playground/libs/gltrack"
+)
+
+// floatsPerVertex é o tamanho, em floats, de um vértice no buffer interleaved
+// montado na CPU: pos(3) + normal(3) + uv(2) + joints(4) + weights(4) +
+// tangent(4) + color(4) + uv1(2). O buffer enviado ao OpenGL segue o
+// vertexLayout da mesh.
+const floatsPerVertex = 26
+
+// Upload cria os recursos OpenGL (texturas, VAO/VBO/EBO) a partir de dados
+// já decodificados. Precisa ser chamado na thread que possui o contexto GL.
+// Para enviar aos poucos, entre frames, use NewUploader.
+func Upload(data *ModelData) (*GLTFModel, error) {
+	u := NewUploader(data)
+	for !u.Done() {
+		if err := u.Step(); err != nil {
+			return nil, err
+		}
+	}
+	return u.Model(), nil
+}
+
+// uploadMesh converte uma MeshData em VAO/VBO/EBO do OpenGL.
+func uploadMesh(meshData *MeshData) (*GLTFMesh, error) {
+	posData := meshData.Positions
+	normalData := meshData.Normals
+	tangentData := meshData.Tangents
+	uvData := meshData.UVs
+	uv1Data := meshData.UVs1
+	colorData := meshData.Colors
+	jointData := meshData.Joints
+	weightData := meshData.Weights
+	indices := meshData.Indices
+
+	if len(posData) == 0 {
+		return nil, fmt.Errorf("mesh sem vértices")
+	}
+
+	// ---- Monta buffer interleaved (ver floatsPerVertex) ----
+	// Os índices de joint vão como float: são exatos até 2^24, muito além do
+	// limite de joints do shader.
+	vertCount := len(posData)
+	buf := make([]float32, 0, vertCount*floatsPerVertex)
+
+	for i := 0; i < vertCount; i++ {
+		// pos
+		buf = append(buf, posData[i][0], posData[i][1], posData[i][2])
+		// normal
+		if i < len(normalData) {
+			buf = append(buf, normalData[i][0], normalData[i][1], normalData[i][2])
+		} else {
+			buf = append(buf, 0, 1, 0)
+		}
+		// uv
+		if uvData != nil && i < len(uvData) {
+			buf = append(buf, uvData[i][0], uvData[i][1])
+		} else {
+			buf = append(buf, 0, 0)
+		}
+		// joints + weights
+		if jointData != nil && i < len(jointData) && i < len(weightData) {
+			j := jointData[i]
+			buf = append(buf, float32(j[0]), float32(j[1]), float32(j[2]), float32(j[3]))
+			buf = append(buf, weightData[i][0], weightData[i][1], weightData[i][2], weightData[i][3])
+		} else {
+			buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 0)
+		}
+		// tangent (xyz + handedness)
+		if i < len(tangentData) {
+			t := tangentData[i]
+			buf = append(buf, t[0], t[1], t[2], t[3])
+		} else {
+			buf = append(buf, 1, 0, 0, 1)
+		}
+		// color
+		if i < len(colorData) {
+			c := colorData[i]
+			buf = append(buf, c[0], c[1], c[2], c[3])
+		} else {
+			buf = append(buf, 1, 1, 1, 1)
+		}
+		// uv1
+		if i < len(uv1Data) {
+			buf = append(buf, uv1Data[i][0], uv1Data[i][1])
+		} else {
+			buf = append(buf, 0, 0)
+		}
+	}
+
+	// ---- Cria VAO/VBO/EBO ----
+	var vao, vbo uint32
+	gl.GenVertexArrays(1, &vao)
+	gl.GenBuffers(1, &vbo)
+	label := fmt.Sprintf("gltfloader: mesh %q", meshData.Name)
+	gltrack.Track(gltrack.VertexArray, vao, label)
+	gltrack.Track(gltrack.Buffer, vbo, label)
+
+	gl.BindVertexArray(vao)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+
+	// Atributos quantizados sobem no formato original. Morph targets somam
+	// deltas float no buffer da CPU, então essas meshes ficam todas em float.
+	formats := meshData.Formats
+	if len(meshData.Targets) > 0 {
+		formats = AttributeFormats{}
+	}
+	layout := newVertexLayout(formats)
+
+	var morph *Morph
+	if len(meshData.Targets) > 0 {
+		// Mantém o buffer original na CPU para reaplicar os deltas quando os
+		// pesos mudarem; o VBO recebe já a pose com os pesos iniciais.
+		morph = &Morph{
+			Targets: meshData.Targets,
+			Weights: append([]float32(nil), meshData.MorphWeights...),
+			base:    buf,
+			current: make([]float32, len(buf)),
+			vbo:     vbo,
+		}
+		if len(morph.Weights) < len(morph.Targets) {
+			morph.Weights = append(morph.Weights, make([]float32, len(morph.Targets)-len(morph.Weights))...)
+		}
+		morph.blend()
+		gl.BufferData(gl.ARRAY_BUFFER, len(morph.current)*4, gl.Ptr(morph.current), gl.DYNAMIC_DRAW)
+	} else if formats == (AttributeFormats{}) {
+		gl.BufferData(gl.ARRAY_BUFFER, len(buf)*4, gl.Ptr(buf), gl.STATIC_DRAW)
+	} else {
+		packed := layout.pack(buf)
+		gl.BufferData(gl.ARRAY_BUFFER, len(packed), gl.Ptr(packed), gl.STATIC_DRAW)
+	}
+
+	layout.bind()
+
+	var instanceVBO uint32
+	if len(meshData.Instances) > 0 {
+		instanceVBO = uploadInstances(meshData.Instances)
+		gltrack.Track(gltrack.Buffer, instanceVBO, label)
+	}
+
+	glMesh := &GLTFMesh{
+		Name:      meshData.Name,
+		VAO:       vao,
+		vbo:       vbo,
+		Mode:      meshData.Mode,
+		Material:  meshData.Material,
+		Transform: meshData.Transform,
+		Node:      meshData.Node,
+		Skin:      meshData.Skin,
+		Morph:     morph,
+
+		InstanceCount:    int32(len(meshData.Instances)),
+		VariantMaterials: meshData.VariantMaterials,
+		instanceVBO:      instanceVBO,
+		defaultMaterial:  meshData.Material,
+	}
+
+	if len(indices) > 0 {
+		var ebo uint32
+		gl.GenBuffers(1, &ebo)
+		gltrack.Track(gltrack.Buffer, ebo, label)
+		glMesh.ebo = ebo
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
+		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+		glMesh.HasIndices = true
+		glMesh.IndexCount = int32(len(indices))
+	} else {
+		glMesh.VertexCount = int32(vertCount)
+	}
+
+	gl.BindVertexArray(0)
+
+	return glMesh, nil
+}
+
+// Release deleta o VAO e os buffers da mesh. Depois disso a mesh não pode
+// mais ser desenhada; chamar de novo não faz nada. Precisa do contexto GL.
+func (m *GLTFMesh) Release() {
+	if m.VAO != 0 {
+		gl.DeleteVertexArrays(1, &m.VAO)
+		gltrack.Untrack(gltrack.VertexArray, m.VAO)
+		m.VAO = 0
+	}
+	for _, buf := range []*uint32{&m.vbo, &m.ebo, &m.instanceVBO} {
+		if *buf != 0 {
+			gl.DeleteBuffers(1, buf)
+			gltrack.Untrack(gltrack.Buffer, *buf)
+			*buf = 0
+		}
+	}
+	if m.Morph != nil {
+		m.Morph.vbo = 0
+	}
+	m.IndexCount = 0
+	m.VertexCount = 0
+	m.InstanceCount = 0
+}
+
+// GLMode retorna o enum OpenGL da topologia da mesh, para DrawArrays/DrawElements.
+func (m *GLTFMesh) GLMode() uint32 {
+	switch m.Mode {
+	case ModePoints:
+		return gl.POINTS
+	case ModeLines:
+		return gl.LINES
+	case ModeLineLoop:
+		return gl.LINE_LOOP
+	case ModeLineStrip:
+		return gl.LINE_STRIP
+	case ModeTriangleStrip:
+		return gl.TRIANGLE_STRIP
+	case ModeTriangleFan:
+		return gl.TRIANGLE_FAN
+	}
+	return gl.TRIANGLES
+}
+
+// ApplyMorph reenvia ao VBO a pose deformada pelos morph targets, se os
+// pesos mudaram desde a última chamada. Precisa do contexto GL.
+func (m *GLTFMesh) ApplyMorph() {
+	if m.Morph == nil || !m.Morph.dirty || m.Morph.vbo == 0 {
+		return
+	}
+	m.Morph.blend()
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.Morph.vbo)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(m.Morph.current)*4, gl.Ptr(m.Morph.current))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	m.Morph.dirty = false
+}
+
+// uploadImageToGL sobe uma imagem RGBA já decodificada como textura OpenGL,
+// configurada com o wrap e os filtros do sampler.
+func uploadImageToGL(rgba *image.RGBA, sampler Sampler) uint32 {
+	w := int32(rgba.Bounds().Dx())
+	h := int32(rgba.Bounds().Dy())
+
+	// Não fazemos flip vertical: glTexImage2D mapeia o primeiro pixel para texcoord (0,0),
+	// e glTF UV (0,0) é o topo-esquerda da imagem, que coincide com o primeiro pixel
+	// decodificado de PNG/JPEG. As convenções se cancelam.
+
+	var texID uint32
+	gl.GenTextures(1, &texID)
+	gl.BindTexture(gl.TEXTURE_2D, texID)
+
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, glWrap(sampler.WrapS))
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, glWrap(sampler.WrapT))
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, glFilter(sampler.MinFilter))
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, glFilter(sampler.MagFilter))
+
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, w, h, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
+	if sampler.MinFilter.UsesMipmaps() {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
+
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return texID
+}
+
+func glWrap(w WrapMode) int32 {
+	switch w {
+	case WrapClampToEdge:
+		return gl.CLAMP_TO_EDGE
+	case WrapMirroredRepeat:
+		return gl.MIRRORED_REPEAT
+	}
+	return gl.REPEAT
+}
+
+func glFilter(f Filter) int32 {
+	switch f {
+	case FilterNearest:
+		return gl.NEAREST
+	case FilterNearestMipmapNearest:
+		return gl.NEAREST_MIPMAP_NEAREST
+	case FilterLinearMipmapNearest:
+		return gl.LINEAR_MIPMAP_NEAREST
+	case FilterNearestMipmapLinear:
+		return gl.NEAREST_MIPMAP_LINEAR
+	case FilterLinearMipmapLinear:
+		return gl.LINEAR_MIPMAP_LINEAR
+	}
+	return gl.LINEAR
+}
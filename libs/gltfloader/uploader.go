@@ -0,0 +1,126 @@
+package gltfloader
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Uploader envia um ModelData ao OpenGL em passos pequenos (uma textura ou
+// uma primitiva por Step), para que o envio de modelos grandes possa ser
+// espalhado por vários frames. Todos os métodos precisam ser chamados na
+// thread que possui o contexto GL.
+type Uploader struct {
+	data  *ModelData
+	model *GLTFModel
+
+	texOrder []int // índices de textura glTF, em ordem estável
+	uploaded map[uploadKey]uint32
+	nextTex  int
+	nextMesh int
+	failed   bool
+}
+
+// uploadKey identifica um texture object dentro de um modelo: a mesma imagem
+// pode ser usada por várias texturas com samplers diferentes, e cada par
+// (imagem, sampler) vira um único texture object, que vem do cache
+// compartilhado entre modelos (ver acquireTexture).
+type uploadKey struct {
+	image   int
+	sampler Sampler
+}
+
+// NewUploader prepara o envio de data. Nenhum recurso OpenGL é criado antes
+// do primeiro Step.
+func NewUploader(data *ModelData) *Uploader {
+	texOrder := make([]int, 0, len(data.Textures))
+	for texIdx := range data.Textures {
+		texOrder = append(texOrder, texIdx)
+	}
+	sort.Ints(texOrder)
+
+	return &Uploader{
+		data:     data,
+		texOrder: texOrder,
+		uploaded: make(map[uploadKey]uint32),
+		model: &GLTFModel{
+			Nodes:      cloneNodes(data.Nodes),
+			Skins:      cloneSkins(data.Skins),
+			Animations: data.Animations, // somente leitura, compartilhadas
+			Materials:  data.Materials,
+			Cameras:    cloneCameras(data.Cameras),
+			Lights:     cloneLights(data.Lights),
+			Textures:   make(map[int]uint32, len(data.Textures)),
+			Report:     data.Report,
+			Variants:   data.Variants,
+		},
+	}
+}
+
+// Steps retorna o número total de passos do envio.
+func (u *Uploader) Steps() int {
+	return len(u.texOrder) + len(u.data.Meshes)
+}
+
+// Completed retorna quantos passos já foram executados.
+func (u *Uploader) Completed() int {
+	return u.nextTex + u.nextMesh
+}
+
+// Done informa se não há mais passos (ou se um passo falhou).
+func (u *Uploader) Done() bool {
+	return u.failed || u.Completed() == u.Steps()
+}
+
+// Step executa o próximo passo do envio. Em caso de erro, tudo o que já foi
+// enviado é liberado e o Uploader fica Done.
+func (u *Uploader) Step() error {
+	if u.Done() {
+		return nil
+	}
+
+	if u.nextTex < len(u.texOrder) {
+		texIdx := u.texOrder[u.nextTex]
+		u.nextTex++
+		u.uploadTexture(texIdx)
+		return nil
+	}
+
+	meshData := u.data.Meshes[u.nextMesh]
+	u.nextMesh++
+	glMesh, err := uploadMesh(meshData)
+	if err != nil {
+		// Não deixa para trás o que já foi enviado
+		u.failed = true
+		u.model.Release()
+		return fmt.Errorf("gltfloader: falha ao enviar mesh %q: %w", meshData.Name, err)
+	}
+	u.model.Meshes = append(u.model.Meshes, glMesh)
+	return nil
+}
+
+// Model retorna o modelo enviado. Só é válido depois de Done sem erro.
+func (u *Uploader) Model() *GLTFModel {
+	return u.model
+}
+
+func (u *Uploader) uploadTexture(texIdx int) {
+	tex := u.data.Textures[texIdx]
+	img, ok := u.data.Images[tex.Image]
+	if !ok {
+		return
+	}
+
+	key := uploadKey{tex.Image, tex.Sampler}
+	id, ok := u.uploaded[key]
+	if !ok {
+		hash := tex.Hash
+		if hash == (ImageHash{}) {
+			// ModelData montado à mão, sem passar pelo decode
+			hash = hashImage(img)
+		}
+		id = acquireTexture(img, hash, tex.Sampler, fmt.Sprintf("gltfloader: imagem %d", tex.Image))
+		u.uploaded[key] = id
+		u.model.acquiredTextures = append(u.model.acquiredTextures, id)
+	}
+	u.model.Textures[texIdx] = id
+}
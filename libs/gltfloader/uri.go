@@ -0,0 +1,157 @@
+package gltfloader
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/qmuntal/gltf"
+)
+
+// emptyDataURI substitui o uri de buffers que o decoder da qmuntal/gltf não
+// deve tentar ler.
+const emptyDataURI = "data:application/octet-stream;base64,"
+
+// uriFS resolve os URIs relativos de um glTF contra dir, o diretório do
+// arquivo dentro de fsys. Diferente de fs.Sub, aceita "../" enquanto o
+// caminho limpo não sair da raiz de fsys; caminhos absolutos são recusados.
+type uriFS struct {
+	fsys fs.FS
+	dir  string
+}
+
+func (u uriFS) Open(uri string) (fs.File, error) {
+	name := path.Join(u.dir, uri)
+	if path.IsAbs(uri) || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: uri, Err: fs.ErrInvalid}
+	}
+	return u.fsys.Open(name)
+}
+
+// relativeURI confere um URI de recurso externo já sem escapes: esquemas
+// e caminhos absolutos são recusados, "../" é permitido.
+func relativeURI(uri string) error {
+	if u, err := url.Parse(uri); err == nil && u.Scheme != "" {
+		return fmt.Errorf("esquema de URI não suportado: %q", u.Scheme)
+	}
+	if path.IsAbs(uri) {
+		return fmt.Errorf("caminho absoluto não suportado: %q", uri)
+	}
+	return nil
+}
+
+// detachExternalBuffers troca o uri dos buffers externos por um data URI
+// vazio e retorna os URIs originais por índice do buffer. O decoder da
+// qmuntal/gltf recusa "../" em buffers; eles são lidos depois, em
+// loadExternalBuffers.
+func detachExternalBuffers(raw []byte) ([]byte, map[int]string) {
+	uris := map[int]string{}
+	out := patchBuffers(raw, func(i int, b map[string]json.RawMessage) bool {
+		var uri string
+		if json.Unmarshal(b["uri"], &uri) != nil || strings.HasPrefix(uri, "data:") {
+			return false
+		}
+		if u, err := url.Parse(uri); err == nil && u.Scheme != "" {
+			return false
+		}
+		uris[i] = uri
+		b["uri"], _ = json.Marshal(emptyDataURI)
+		return true
+	})
+	return out, uris
+}
+
+// loadExternalBuffers lê de fsys os buffers separados por
+// detachExternalBuffers e devolve o uri original a cada um.
+func loadExternalBuffers(doc *gltf.Document, uris map[int]string, fsys fs.FS) error {
+	for i, uri := range uris {
+		if i >= len(doc.Buffers) {
+			continue
+		}
+		b := doc.Buffers[i]
+		// Mesma limpeza que o decoder faz nos URIs de imagem
+		uri = strings.TrimPrefix(strings.ReplaceAll(uri, `\`, "/"), "./")
+		name, err := url.PathUnescape(uri)
+		if err != nil {
+			return fmt.Errorf("buffer %d: uri inválido %q: %w", i, uri, err)
+		}
+		if err := relativeURI(name); err != nil {
+			return fmt.Errorf("buffer %d: %w", i, err)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("buffer %d: %w", i, err)
+		}
+		if len(data) > b.ByteLength {
+			data = data[:b.ByteLength:b.ByteLength]
+		}
+		b.URI = name
+		b.Data = data
+	}
+	return nil
+}
+
+// patchBuffers reescreve o array buffers do JSON de raw, o .gltf ou o .glb
+// inteiro. patch recebe cada buffer e informa se o alterou. O buffer 0 de
+// um GLB sem uri é o chunk BIN e não é passado. Se nada mudar (ou o arquivo
+// não for legível), raw volta inalterado e o decoder reporta o erro.
+func patchBuffers(raw []byte, patch func(i int, b map[string]json.RawMessage) bool) []byte {
+	const glbMagic = "glTF"
+	const glbJSONChunk = 0x4e4f534a
+
+	jsonData := raw
+	isGLB := len(raw) >= 20 && string(raw[:4]) == glbMagic
+	if isGLB {
+		n := int(binary.LittleEndian.Uint32(raw[12:]))
+		if binary.LittleEndian.Uint32(raw[16:]) != glbJSONChunk || 20+n > len(raw) {
+			return raw
+		}
+		jsonData = raw[20 : 20+n]
+	}
+
+	var top map[string]json.RawMessage
+	var buffers []map[string]json.RawMessage
+	if json.Unmarshal(jsonData, &top) != nil || json.Unmarshal(top["buffers"], &buffers) != nil {
+		return raw
+	}
+
+	patched := false
+	for i, b := range buffers {
+		if _, ok := b["uri"]; isGLB && i == 0 && !ok {
+			continue
+		}
+		if patch(i, b) {
+			patched = true
+		}
+	}
+	if !patched {
+		return raw
+	}
+
+	top["buffers"], _ = json.Marshal(buffers)
+	out, err := json.Marshal(top)
+	if err != nil {
+		return raw
+	}
+	if !isGLB {
+		return out
+	}
+
+	// Reescreve o chunk JSON (alinhado a 4 bytes com espaços) e o tamanho
+	// total do GLB; o chunk BIN segue igual.
+	for len(out)%4 != 0 {
+		out = append(out, ' ')
+	}
+	rest := raw[20+len(jsonData):]
+	glb := make([]byte, 20, 20+len(out)+len(rest))
+	copy(glb, raw[:12])
+	binary.LittleEndian.PutUint32(glb[8:], uint32(20+len(out)+len(rest)))
+	binary.LittleEndian.PutUint32(glb[12:], uint32(len(out)))
+	binary.LittleEndian.PutUint32(glb[16:], glbJSONChunk)
+	glb = append(glb, out...)
+	return append(glb, rest...)
+}
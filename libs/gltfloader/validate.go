@@ -0,0 +1,415 @@
+package gltfloader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/joaqu1m/gogl-playground/libs/logger"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/ext/lightspunctual"
+	"github.com/qmuntal/gltf/ext/texturetransform"
+	"github.com/qmuntal/gltf/ext/unlit"
+)
+
+// Severity é a gravidade de um problema encontrado no documento.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "error"
+}
+
+// Issue é um problema do documento. Path é um JSON pointer para o objeto
+// glTF em questão, como "/meshes/3/primitives/0".
+type Issue struct {
+	Severity Severity
+	Path     string
+	Message  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s %s: %s", i.Severity, i.Path, i.Message)
+}
+
+// Report junta os problemas encontrados ao validar e decodificar um documento.
+type Report struct {
+	Issues []Issue
+}
+
+func (r *Report) add(sev Severity, path, format string, args ...any) {
+	r.Issues = append(r.Issues, Issue{Severity: sev, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// HasErrors informa se algum problema tem severidade SeverityError.
+func (r *Report) HasErrors() bool {
+	for _, i := range r.Issues {
+		if i.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Log escreve cada problema no logger, com o nível da severidade.
+func (r *Report) Log() {
+	for _, i := range r.Issues {
+		switch i.Severity {
+		case SeverityError:
+			logger.Errorf("gltfloader: %s: %s", i.Path, i.Message)
+		case SeverityWarning:
+			logger.Warnf("gltfloader: %s: %s", i.Path, i.Message)
+		default:
+			logger.Infof("gltfloader: %s: %s", i.Path, i.Message)
+		}
+	}
+}
+
+// ValidationError é retornado quando a validação encontra erros que impedem
+// o carregamento. Report tem a lista completa.
+type ValidationError struct {
+	Report *Report
+}
+
+func (e *ValidationError) Error() string {
+	var msgs []string
+	for _, i := range e.Report.Issues {
+		if i.Severity == SeverityError {
+			msgs = append(msgs, i.Path+": "+i.Message)
+		}
+	}
+	return fmt.Sprintf("gltfloader: documento inválido (%d erros): %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+// supportedExtensions são as extensões que o loader entende. Documentos com
+// outras extensões em extensionsRequired são recusados.
+var supportedExtensions = map[string]bool{
+	lightspunctual.ExtensionName:   true,
+	texturetransform.ExtensionName: true,
+	unlit.ExtensionName:            true,
+	emissiveStrengthExtension:      true,
+	clearcoatExtension:             true,
+	sheenExtension:                 true,
+	transmissionExtension:          true,
+	iorExtension:                   true,
+	specularExtension:              true,
+	quantizationExtension:          true,
+	meshoptExtension:               true,
+	instancingExtension:            true,
+	variantsExtension:              true,
+}
+
+// Validate confere a estrutura do documento: índices fora do range,
+// accessors e buffer views que não cabem nos dados, contagens de accessors
+// divergentes, ciclos na hierarquia de nós e extensões obrigatórias não
+// suportadas.
+// Problemas que dependem dos dados (triângulos degenerados, imagens que não
+// decodificam) são adicionados ao Report durante o Decode.
+func Validate(doc *gltf.Document) *Report {
+	r := &Report{}
+
+	for i, ext := range doc.ExtensionsRequired {
+		if !supportedExtensions[ext] {
+			r.add(SeverityError, fmt.Sprintf("/extensionsRequired/%d", i), "extensão obrigatória não suportada: %s", ext)
+		}
+	}
+	for i, ext := range doc.ExtensionsUsed {
+		if !supportedExtensions[ext] {
+			r.add(SeverityInfo, fmt.Sprintf("/extensionsUsed/%d", i), "extensão ignorada: %s", ext)
+		}
+	}
+
+	for i, bv := range doc.BufferViews {
+		path := fmt.Sprintf("/bufferViews/%d", i)
+		if bv.Buffer < 0 || bv.Buffer >= len(doc.Buffers) {
+			r.add(SeverityError, path+"/buffer", "buffer %d fora do range", bv.Buffer)
+			continue
+		}
+		if size := len(doc.Buffers[bv.Buffer].Data); bv.ByteOffset < 0 || bv.ByteLength < 0 || bv.ByteOffset+bv.ByteLength > size {
+			r.add(SeverityError, path, "bytes [%d, %d) fora do buffer %d, que tem %d bytes", bv.ByteOffset, bv.ByteOffset+bv.ByteLength, bv.Buffer, size)
+		}
+	}
+	for i, acr := range doc.Accessors {
+		validateAccessorRange(doc, acr, fmt.Sprintf("/accessors/%d", i), r)
+	}
+
+	variantCount := len(decodeVariants(doc))
+	for m, mesh := range doc.Meshes {
+		for p, prim := range mesh.Primitives {
+			path := fmt.Sprintf("/meshes/%d/primitives/%d", m, p)
+			validatePrimitive(doc, prim, path, r)
+			validateVariantMappings(doc, prim, path, variantCount, r)
+		}
+	}
+
+	for i, skin := range doc.Skins {
+		validateSkin(doc, skin, fmt.Sprintf("/skins/%d", i), r)
+	}
+
+	for i, node := range doc.Nodes {
+		path := fmt.Sprintf("/nodes/%d", i)
+		checkIndex(r, SeverityError, path+"/mesh", node.Mesh, len(doc.Meshes), "mesh")
+		checkIndex(r, SeverityError, path+"/skin", node.Skin, len(doc.Skins), "skin")
+		checkIndex(r, SeverityError, path+"/camera", node.Camera, len(doc.Cameras), "camera")
+		for c, child := range node.Children {
+			checkIndex(r, SeverityError, fmt.Sprintf("%s/children/%d", path, c), &child, len(doc.Nodes), "node")
+		}
+	}
+
+	for i, scene := range doc.Scenes {
+		for n, nodeIdx := range scene.Nodes {
+			checkIndex(r, SeverityError, fmt.Sprintf("/scenes/%d/nodes/%d", i, n), &nodeIdx, len(doc.Nodes), "node")
+		}
+	}
+	checkIndex(r, SeverityError, "/scene", doc.Scene, len(doc.Scenes), "scene")
+	validateNodeCycles(doc, r)
+
+	for i, tex := range doc.Textures {
+		path := fmt.Sprintf("/textures/%d", i)
+		if tex.Source == nil {
+			r.add(SeverityWarning, path, "textura sem source")
+		}
+		checkIndex(r, SeverityWarning, path+"/source", tex.Source, len(doc.Images), "imagem")
+		checkIndex(r, SeverityWarning, path+"/sampler", tex.Sampler, len(doc.Samplers), "sampler")
+	}
+
+	for i, mat := range doc.Materials {
+		validateMaterialTextures(doc, mat, fmt.Sprintf("/materials/%d", i), r)
+	}
+
+	return r
+}
+
+// validateAccessorRange confere se os elementos do accessor cabem no buffer
+// view: byteOffset + (count-1)*stride + tamanho do elemento.
+func validateAccessorRange(doc *gltf.Document, acr *gltf.Accessor, path string, r *Report) {
+	if acr.BufferView == nil {
+		return
+	}
+	if *acr.BufferView < 0 || *acr.BufferView >= len(doc.BufferViews) {
+		r.add(SeverityError, path+"/bufferView", "buffer view %d fora do range", *acr.BufferView)
+		return
+	}
+	bv := doc.BufferViews[*acr.BufferView]
+
+	size := gltf.SizeOfElement(acr.ComponentType, acr.Type)
+	if size == 0 {
+		r.add(SeverityError, path, "componentType %d inválido para %s", acr.ComponentType, acr.Type)
+		return
+	}
+	stride := size
+	if bv.ByteStride != 0 {
+		stride = bv.ByteStride
+	}
+	end := acr.ByteOffset
+	if acr.Count > 0 {
+		end += (acr.Count-1)*stride + size
+	}
+	if acr.ByteOffset < 0 || acr.Count < 0 || end > bv.ByteLength {
+		r.add(SeverityError, path, "%d elementos a partir do byte %d precisam de %d bytes, o buffer view %d tem %d", acr.Count, acr.ByteOffset, end, *acr.BufferView, bv.ByteLength)
+	}
+}
+
+// validateSkin confere os joints e as inverse bind matrices de uma skin.
+func validateSkin(doc *gltf.Document, skin *gltf.Skin, path string, r *Report) {
+	for j := range skin.Joints {
+		checkIndex(r, SeverityError, fmt.Sprintf("%s/joints/%d", path, j), &skin.Joints[j], len(doc.Nodes), "node")
+	}
+	checkIndex(r, SeverityError, path+"/skeleton", skin.Skeleton, len(doc.Nodes), "node")
+
+	ibm := skin.InverseBindMatrices
+	checkIndex(r, SeverityError, path+"/inverseBindMatrices", ibm, len(doc.Accessors), "accessor")
+	if ibm != nil && *ibm >= 0 && *ibm < len(doc.Accessors) {
+		if acr := doc.Accessors[*ibm]; acr.Count < len(skin.Joints) {
+			r.add(SeverityWarning, path+"/inverseBindMatrices", "%d matrizes para %d joints; o resto usa a identidade", acr.Count, len(skin.Joints))
+		}
+	}
+}
+
+// validateNodeCycles procura ciclos em children, que fariam o percurso da
+// scene graph recursar para sempre. Cada ciclo é reportado na aresta que o
+// fecha.
+func validateNodeCycles(doc *gltf.Document, r *Report) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(doc.Nodes))
+
+	var visit func(idx int)
+	visit = func(idx int) {
+		state[idx] = visiting
+		for c, child := range doc.Nodes[idx].Children {
+			if child < 0 || child >= len(doc.Nodes) {
+				continue
+			}
+			switch state[child] {
+			case visiting:
+				r.add(SeverityError, fmt.Sprintf("/nodes/%d/children/%d", idx, c), "ciclo na hierarquia: node %d é ancestral de %d", child, idx)
+			case unvisited:
+				visit(child)
+			}
+		}
+		state[idx] = done
+	}
+
+	for i := range doc.Nodes {
+		if state[i] == unvisited {
+			visit(i)
+		}
+	}
+}
+
+func validatePrimitive(doc *gltf.Document, prim *gltf.Primitive, path string, r *Report) {
+	posIdx, ok := prim.Attributes[gltf.POSITION]
+	if !ok {
+		r.add(SeverityError, path+"/attributes", "primitiva sem POSITION")
+	}
+
+	count := -1
+	if ok && posIdx >= 0 && posIdx < len(doc.Accessors) {
+		count = doc.Accessors[posIdx].Count
+	}
+	for name, idx := range prim.Attributes {
+		attrPath := path + "/attributes/" + name
+		if idx < 0 || idx >= len(doc.Accessors) {
+			r.add(SeverityError, attrPath, "accessor %d fora do range", idx)
+			continue
+		}
+		if count >= 0 && doc.Accessors[idx].Count != count {
+			r.add(SeverityError, attrPath, "accessor com %d elementos, POSITION tem %d", doc.Accessors[idx].Count, count)
+		}
+	}
+	for t, target := range prim.Targets {
+		for name, idx := range target {
+			attrPath := fmt.Sprintf("%s/targets/%d/%s", path, t, name)
+			if idx < 0 || idx >= len(doc.Accessors) {
+				r.add(SeverityError, attrPath, "accessor %d fora do range", idx)
+				continue
+			}
+			if count >= 0 && doc.Accessors[idx].Count != count {
+				r.add(SeverityError, attrPath, "accessor com %d elementos, POSITION tem %d", doc.Accessors[idx].Count, count)
+			}
+		}
+	}
+
+	checkIndex(r, SeverityError, path+"/indices", prim.Indices, len(doc.Accessors), "accessor")
+	checkIndex(r, SeverityError, path+"/material", prim.Material, len(doc.Materials), "material")
+
+	if _, hasJoints := prim.Attributes[gltf.JOINTS_0]; hasJoints {
+		if _, hasWeights := prim.Attributes[gltf.WEIGHTS_0]; !hasWeights {
+			r.add(SeverityError, path+"/attributes", "JOINTS_0 sem WEIGHTS_0")
+		}
+	}
+}
+
+// validateMaterialTextures confere os índices de textura do material, nos
+// slots do core e nas extensões PBR.
+func validateMaterialTextures(doc *gltf.Document, mat *gltf.Material, path string, r *Report) {
+	check := func(slot string, idx *int) {
+		if idx == nil {
+			return
+		}
+		if *idx < 0 || *idx >= len(doc.Textures) {
+			r.add(SeverityWarning, path+slot+"/index", "textura %d não existe", *idx)
+		}
+	}
+
+	if pbr := mat.PBRMetallicRoughness; pbr != nil {
+		if pbr.BaseColorTexture != nil {
+			check("/pbrMetallicRoughness/baseColorTexture", &pbr.BaseColorTexture.Index)
+		}
+		if pbr.MetallicRoughnessTexture != nil {
+			check("/pbrMetallicRoughness/metallicRoughnessTexture", &pbr.MetallicRoughnessTexture.Index)
+		}
+	}
+	if mat.NormalTexture != nil {
+		check("/normalTexture", mat.NormalTexture.Index)
+	}
+	if mat.OcclusionTexture != nil {
+		check("/occlusionTexture", mat.OcclusionTexture.Index)
+	}
+	if mat.EmissiveTexture != nil {
+		check("/emissiveTexture", &mat.EmissiveTexture.Index)
+	}
+
+	// As extensões passam pelo mesmo parse do decode, para conferir as
+	// referências que de fato chegam ao upload
+	var ext Material
+	decodeMaterialExtensions(mat, &ext)
+	checkRef := func(extension, slot string, ref *TextureRef) {
+		if ref != nil {
+			check("/extensions/"+extension+"/"+slot, &ref.Index)
+		}
+	}
+	if cc := ext.Clearcoat; cc != nil {
+		checkRef(clearcoatExtension, "clearcoatTexture", cc.Texture)
+		checkRef(clearcoatExtension, "clearcoatRoughnessTexture", cc.RoughnessTexture)
+		checkRef(clearcoatExtension, "clearcoatNormalTexture", cc.NormalTexture)
+	}
+	if sh := ext.Sheen; sh != nil {
+		checkRef(sheenExtension, "sheenColorTexture", sh.ColorTexture)
+		checkRef(sheenExtension, "sheenRoughnessTexture", sh.RoughnessTexture)
+	}
+	if tr := ext.Transmission; tr != nil {
+		checkRef(transmissionExtension, "transmissionTexture", tr.Texture)
+	}
+	if sp := ext.Specular; sp != nil {
+		checkRef(specularExtension, "specularTexture", sp.Texture)
+		checkRef(specularExtension, "specularColorTexture", sp.ColorTexture)
+	}
+}
+
+// checkIndex registra um problema se idx não for nil e estiver fora de
+// [0, n).
+func checkIndex(r *Report, sev Severity, path string, idx *int, n int, what string) {
+	if idx != nil && (*idx < 0 || *idx >= n) {
+		r.add(sev, path, "%s %d fora do range", what, *idx)
+	}
+}
+
+// checkTriangles confere os índices de uma primitiva contra o número de
+// vértices e conta os triângulos degenerados (vértices repetidos ou área
+// zero) de listas de triângulos, num único warning. Strips e fans não são
+// contados: strips usam triângulos degenerados de propósito para emendar
+// sequências. Índices fora do range são erro: a primitiva não pode ser usada.
+func checkTriangles(mode PrimitiveMode, indices []uint32, positions [][3]float32, path string, r *Report) error {
+	for i, idx := range indices {
+		if int(idx) >= len(positions) {
+			r.add(SeverityError, path+"/indices", "índice %d (posição %d) fora do range, a primitiva tem %d vértices", idx, i, len(positions))
+			return fmt.Errorf("índice %d fora do range (%d vértices)", idx, len(positions))
+		}
+	}
+	if mode != ModeTriangles {
+		return nil
+	}
+
+	tris := triangleList(mode, indices, len(positions))
+	degenerate := 0
+	for t := 0; t+2 < len(tris); t += 3 {
+		a, b, c := tris[t], tris[t+1], tris[t+2]
+		if a == b || b == c || a == c {
+			degenerate++
+			continue
+		}
+		n := cross3(sub3(positions[b], positions[a]), sub3(positions[c], positions[a]))
+		if dot3(n, n) == 0 {
+			degenerate++
+		}
+	}
+	if degenerate > 0 {
+		r.add(SeverityWarning, path, "%d de %d triângulos degenerados", degenerate, len(tris)/3)
+	}
+	return nil
+}
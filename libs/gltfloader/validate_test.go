@@ -0,0 +1,141 @@
+package gltfloader
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// issuesAt retorna os problemas do report com a severidade dada cujo path
+// contém sub.
+func issuesAt(r *Report, sev Severity, sub string) []Issue {
+	var out []Issue
+	for _, i := range r.Issues {
+		if i.Severity == sev && strings.Contains(i.Path, sub) {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func TestCheckTrianglesDegenerate(t *testing.T) {
+	positions := [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0}, {2, 0, 0}, {2, 1, 0}}
+	tests := []struct {
+		name     string
+		mode     PrimitiveMode
+		indices  []uint32
+		warnings int
+	}{
+		{"lista sem degenerados", ModeTriangles, []uint32{0, 1, 2, 2, 1, 3}, 0},
+		{"lista com degenerados vira um warning", ModeTriangles, []uint32{0, 1, 2, 0, 0, 1, 1, 1, 3}, 1},
+		// Duas sequências emendadas com índices repetidos (3, 3, 4, 4)
+		{"strip emendada", ModeTriangleStrip, []uint32{0, 1, 2, 3, 3, 4, 4, 5, 3}, 0},
+		{"fan", ModeTriangleFan, []uint32{0, 1, 1, 2}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Report{}
+			if err := checkTriangles(tt.mode, tt.indices, positions, "/meshes/0/primitives/0", r); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(issuesAt(r, SeverityWarning, "")); got != tt.warnings {
+				t.Errorf("%d warnings, want %d: %v", got, tt.warnings, r.Issues)
+			}
+		})
+	}
+}
+
+func TestCheckTrianglesIndexOutOfRange(t *testing.T) {
+	r := &Report{}
+	err := checkTriangles(ModeTriangleStrip, []uint32{0, 1, 9}, [][3]float32{{}, {}, {}}, "/p", r)
+	if err == nil || !r.HasErrors() {
+		t.Errorf("índice fora do range deveria ser erro: err = %v, issues %v", err, r.Issues)
+	}
+}
+
+// validDoc é um documento mínimo válido: um triângulo num nó.
+func validDoc() *gltf.Document {
+	doc := gltf.NewDocument()
+	mesh := 0
+	doc.Meshes = []*gltf.Mesh{{Primitives: []*gltf.Primitive{{
+		Attributes: gltf.PrimitiveAttributes{
+			gltf.POSITION: modeler.WritePosition(doc, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}),
+		},
+	}}}}
+	doc.Nodes = []*gltf.Node{{Mesh: &mesh, Matrix: gltf.DefaultMatrix}}
+	doc.Scenes[0].Nodes = []int{0}
+	return doc
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(doc *gltf.Document)
+		path   string
+	}{
+		{"documento válido", func(*gltf.Document) {}, ""},
+		{"accessor além do buffer view", func(doc *gltf.Document) {
+			doc.Accessors[0].ByteOffset = 1000
+		}, "/accessors/0"},
+		{"buffer view além do buffer", func(doc *gltf.Document) {
+			doc.BufferViews[0].ByteLength = 1 << 20
+		}, "/bufferViews/0"},
+		{"ciclo de nós", func(doc *gltf.Document) {
+			doc.Nodes = append(doc.Nodes, &gltf.Node{Children: []int{0}, Matrix: gltf.DefaultMatrix})
+			doc.Nodes[0].Children = []int{1}
+		}, "/nodes/"},
+		{"joint inexistente", func(doc *gltf.Document) {
+			doc.Skins = []*gltf.Skin{{Joints: []int{7}}}
+		}, "/skins/0"},
+		{"inverse bind matrices inexistente", func(doc *gltf.Document) {
+			ibm := 42
+			doc.Skins = []*gltf.Skin{{Joints: []int{0}, InverseBindMatrices: &ibm}}
+		}, "/skins/0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := validDoc()
+			tt.mutate(doc)
+			r := Validate(doc)
+			if tt.path == "" {
+				if r.HasErrors() {
+					t.Errorf("erros inesperados: %v", r.Issues)
+				}
+				return
+			}
+			if len(issuesAt(r, SeverityError, tt.path)) == 0 {
+				t.Errorf("nenhum erro em %s: %v", tt.path, r.Issues)
+			}
+		})
+	}
+}
+
+func TestValidateMaterialExtensionTextures(t *testing.T) {
+	doc := validDoc()
+	doc.Textures = []*gltf.Texture{{}}
+	doc.Materials = []*gltf.Material{{Extensions: gltf.Extensions{
+		clearcoatExtension:    json.RawMessage(`{"clearcoatFactor": 1, "clearcoatTexture": {"index": 0}, "clearcoatNormalTexture": {"index": 3}}`),
+		sheenExtension:        json.RawMessage(`{"sheenRoughnessTexture": {"index": 5}}`),
+		transmissionExtension: json.RawMessage(`{"transmissionTexture": {"index": 0}}`),
+		specularExtension:     json.RawMessage(`{"specularColorTexture": {"index": -1}}`),
+	}}}
+
+	r := Validate(doc)
+	for _, slot := range []string{
+		"/materials/0/extensions/KHR_materials_clearcoat/clearcoatNormalTexture/index",
+		"/materials/0/extensions/KHR_materials_sheen/sheenRoughnessTexture/index",
+		"/materials/0/extensions/KHR_materials_specular/specularColorTexture/index",
+	} {
+		if len(issuesAt(r, SeverityWarning, slot)) != 1 {
+			t.Errorf("nenhum warning em %s: %v", slot, r.Issues)
+		}
+	}
+	for _, slot := range []string{"clearcoatTexture/", "transmissionTexture/"} {
+		if got := issuesAt(r, SeverityWarning, slot); len(got) != 0 {
+			t.Errorf("warning inesperado em %s: %v", slot, got)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package gltfloader
+
+import (
+	"fmt"
+
+	"github.com/qmuntal/gltf"
+)
+
+// variantsExtension é o KHR_materials_variants: o documento declara
+// variantes por nome e cada primitiva diz qual material usar em cada uma.
+const variantsExtension = "KHR_materials_variants"
+
+// decodeVariants retorna os nomes das variantes do documento. Os índices
+// são os mesmos usados nos mapeamentos das primitivas.
+func decodeVariants(doc *gltf.Document) []string {
+	var ext struct {
+		Variants []struct {
+			Name string `json:"name"`
+		} `json:"variants"`
+	}
+	if !decodeExtension(doc.Extensions, variantsExtension, &ext) {
+		return nil
+	}
+	names := make([]string, len(ext.Variants))
+	for i, v := range ext.Variants {
+		names[i] = v.Name
+	}
+	return names
+}
+
+// variantMapping é um item de "mappings" na extensão da primitiva.
+type variantMapping struct {
+	Material int   `json:"material"`
+	Variants []int `json:"variants"`
+}
+
+func primitiveVariantMappings(prim *gltf.Primitive) []variantMapping {
+	var ext struct {
+		Mappings []variantMapping `json:"mappings"`
+	}
+	decodeExtension(prim.Extensions, variantsExtension, &ext)
+	return ext.Mappings
+}
+
+// validateVariantMappings confere os índices de material e de variante dos
+// mapeamentos de uma primitiva.
+func validateVariantMappings(doc *gltf.Document, prim *gltf.Primitive, path string, variantCount int, r *Report) {
+	for i, m := range primitiveVariantMappings(prim) {
+		mpath := fmt.Sprintf("%s/extensions/%s/mappings/%d", path, variantsExtension, i)
+		checkIndex(r, SeverityError, mpath+"/material", &m.Material, len(doc.Materials), "material")
+		for j := range m.Variants {
+			checkIndex(r, SeverityError, fmt.Sprintf("%s/variants/%d", mpath, j), &m.Variants[j], variantCount, "variante")
+		}
+	}
+}
+
+// decodeVariantMappings devolve o material de cada variante mapeada pela
+// primitiva; variantes sem mapeamento usam o material padrão. Retorna nil
+// sem a extensão. Os índices já foram conferidos por Validate.
+func decodeVariantMappings(prim *gltf.Primitive, materials []*Material) map[int]*Material {
+	mappings := primitiveVariantMappings(prim)
+	if len(mappings) == 0 {
+		return nil
+	}
+	out := make(map[int]*Material)
+	for _, m := range mappings {
+		for _, v := range m.Variants {
+			out[v] = materials[m.Material]
+		}
+	}
+	return out
+}
+
+// VariantIndex retorna o índice da variante com o nome dado, ou -1.
+func (m *GLTFModel) VariantIndex(name string) int {
+	for i, v := range m.Variants {
+		if v == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// ActiveVariant retorna o índice da variante ativa, ou -1 quando as meshes
+// usam os materiais padrão.
+func (m *GLTFModel) ActiveVariant() int {
+	return m.variant - 1
+}
+
+// SetVariant troca o material de todas as meshes para o da variante idx.
+// Meshes sem mapeamento para ela, e qualquer idx fora do range (como -1),
+// voltam ao material padrão. Só troca ponteiros: as texturas de todos os
+// materiais já foram enviadas no upload.
+func (m *GLTFModel) SetVariant(idx int) {
+	if idx < 0 || idx >= len(m.Variants) {
+		idx = -1
+	}
+	for _, mesh := range m.Meshes {
+		if mat, ok := mesh.VariantMaterials[idx]; ok {
+			mesh.Material = mat
+		} else {
+			mesh.Material = mesh.defaultMaterial
+		}
+	}
+	m.variant = idx + 1
+}
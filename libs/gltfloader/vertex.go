@@ -0,0 +1,62 @@
+package gltfloader
+
+import (
+	"fmt"
+
+	"github.com/qmuntal/gltf"
+)
+
+// readVec lê um atributo de vértice VEC2/VEC3/VEC4 como floats. Aceita os
+// tipos quantizados do KHR_mesh_quantization: inteiros normalizados são
+// desnormalizados e os demais convertidos direto.
+func readVec[E [2]float32 | [3]float32 | [4]float32](doc *gltf.Document, acr *gltf.Accessor) ([]E, error) {
+	flat, components, err := readAccessorFloats(doc, acr, acr.Normalized)
+	if err != nil {
+		return nil, err
+	}
+	var zero E
+	if components != len(zero) {
+		return nil, fmt.Errorf("atributo com %d componentes, esperado %d", components, len(zero))
+	}
+	out := make([]E, len(flat)/components)
+	for i := range out {
+		for c := 0; c < components; c++ {
+			out[i][c] = flat[i*components+c]
+		}
+	}
+	return out, nil
+}
+
+// remapVertices reconstrói os atributos por vértice de md a partir de remap:
+// o novo vértice i é uma cópia do vértice remap[i]. Índices não são tocados.
+// Todo atributo por vértice novo de MeshData precisa ser incluído aqui.
+func remapVertices(md *MeshData, remap []uint32) {
+	md.Positions = remapSlice(md.Positions, remap)
+	md.Normals = remapSlice(md.Normals, remap)
+	md.Tangents = remapSlice(md.Tangents, remap)
+	md.UVs = remapSlice(md.UVs, remap)
+	md.UVs1 = remapSlice(md.UVs1, remap)
+	md.Colors = remapSlice(md.Colors, remap)
+	md.Joints = remapSlice(md.Joints, remap)
+	md.Weights = remapSlice(md.Weights, remap)
+	for i := range md.Targets {
+		md.Targets[i].Positions = remapSlice(md.Targets[i].Positions, remap)
+		md.Targets[i].Normals = remapSlice(md.Targets[i].Normals, remap)
+		md.Targets[i].Tangents = remapSlice(md.Targets[i].Tangents, remap)
+	}
+}
+
+// remapSlice aplica remap a um atributo. Atributos ausentes (nil) continuam
+// nil; elementos além do tamanho do atributo ficam zerados.
+func remapSlice[T any](src []T, remap []uint32) []T {
+	if src == nil {
+		return nil
+	}
+	out := make([]T, len(remap))
+	for i, from := range remap {
+		if int(from) < len(src) {
+			out[i] = src[from]
+		}
+	}
+	return out
+}
@@ -0,0 +1,123 @@
+// Package gltrack registra os objetos OpenGL vivos para achar vazamentos.
+//
+// O rastreamento só fica ativo em modo debug, ligado pela variável de ambiente
+// GOGL_DEBUG_GL=1; fora dele Track/Untrack não fazem nada.
+package gltrack
+
+import (
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/joaqu1m/gogl-playground/libs/logger"
+)
+
+// Kind é o tipo de objeto OpenGL. IDs só são únicos dentro do mesmo tipo.
+type Kind int
+
+const (
+	Buffer Kind = iota
+	VertexArray
+	Texture
+	Program
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Buffer:
+		return "buffer"
+	case VertexArray:
+		return "vertex array"
+	case Texture:
+		return "texture"
+	case Program:
+		return "program"
+	}
+	return "desconhecido"
+}
+
+// Object é um objeto OpenGL vivo, com uma descrição de quem o criou.
+type Object struct {
+	Kind  Kind
+	ID    uint32
+	Label string
+}
+
+type key struct {
+	kind Kind
+	id   uint32
+}
+
+var (
+	enabled = os.Getenv("GOGL_DEBUG_GL") == "1"
+
+	mu   sync.Mutex
+	live = make(map[key]string)
+)
+
+// Enabled informa se o rastreamento está ativo.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// SetEnabled liga ou desliga o rastreamento. Objetos criados com ele
+// desligado não aparecem em Live.
+func SetEnabled(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = on
+}
+
+// Track registra um objeto recém-criado. IDs zero são ignorados.
+func Track(kind Kind, id uint32, label string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled || id == 0 {
+		return
+	}
+	live[key{kind, id}] = label
+}
+
+// Untrack remove um objeto que acabou de ser deletado.
+func Untrack(kind Kind, id uint32) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(live, key{kind, id})
+}
+
+// Live lista os objetos ainda vivos, ordenados por tipo e ID.
+func Live() []Object {
+	mu.Lock()
+	defer mu.Unlock()
+
+	out := make([]Object, 0, len(live))
+	for k, label := range live {
+		out = append(out, Object{Kind: k.kind, ID: k.id, Label: label})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// LogLeaks loga cada objeto ainda vivo como warning e retorna quantos são.
+// Deve ser chamado no shutdown, depois de liberar todos os recursos.
+func LogLeaks() int {
+	if !Enabled() {
+		return 0
+	}
+
+	objs := Live()
+	for _, o := range objs {
+		logger.Warnf("gltrack: %s %d ainda vivo (%s)", o.Kind, o.ID, o.Label)
+	}
+	if len(objs) == 0 {
+		logger.Debugf("gltrack: nenhum objeto OpenGL vazado")
+	}
+	return len(objs)
+}